@@ -0,0 +1,205 @@
+package gongular
+
+import (
+	"fmt"
+	"net/textproto"
+	"reflect"
+)
+
+// bindingPlan is the binding of a flat struct compiled when the handler is registered, so that the fields, their
+// keys and converters are not looked up for each request
+type bindingPlan struct {
+	// The index of the special field in the handler
+	index  int
+	place  string
+	stream bool
+	fields []fieldBinding
+
+	// The names of the fields that cannot be bound from strings, i.e. the nested structs
+	unbound []string
+}
+
+// fieldBinding is the compiled binding of a field in a bindingPlan
+type fieldBinding struct {
+	index    int
+	field    reflect.StructField
+	key      string
+	defaults []string
+	required bool
+	optional bool
+	file     bool
+	// pointer is whether a value is allocated for the field, and multi is whether the values are parsed to the
+	// elements of a slice or an array
+	pointer bool
+	multi   bool
+	sep     string
+	// The field with the type that is parsed, which is the element type for pointers, slices and arrays
+	parseField reflect.StructField
+	parse      parseFunc
+}
+
+// compileBinding compiles the binding of the special field with the given name in the handler, or returns nil if
+// there is no such field. nameTag is the struct tag that overrides the keys of the fields.
+func (cs converters) compileBinding(handlerElem reflect.Type, fieldName, place, nameTag string) *bindingPlan {
+	special, ok := handlerElem.FieldByName(fieldName)
+	if !ok {
+		return nil
+	}
+
+	plan := cs.compileStruct(special.Type, place, nameTag)
+	plan.index = special.Index[0]
+	if fieldName == FieldForm {
+		plan.stream = hasMultipartReader(special.Type)
+	} else if fieldName == FieldHeader {
+		for i := range plan.fields {
+			plan.fields[i].key = textproto.CanonicalMIMEHeaderKey(plan.fields[i].key)
+		}
+	}
+	return plan
+}
+
+// compileStruct compiles the binding of each field of the flat struct, the files are left to the form parser
+func (cs converters) compileStruct(tip reflect.Type, place, nameTag string) *bindingPlan {
+	plan := &bindingPlan{
+		place:  place,
+		fields: make([]fieldBinding, 0, tip.NumField()),
+	}
+
+	for i := 0; i < tip.NumField(); i++ {
+		field := tip.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		fb := fieldBinding{
+			index:    i,
+			field:    field,
+			key:      field.Name,
+			required: isRequired(field),
+			optional: isOptional(field),
+			file:     isFileField(field.Type) || field.Type == multipartReaderType,
+			sep:      field.Tag.Get(TagSeparator),
+		}
+
+		if tag, ok := field.Tag.Lookup(nameTag); ok && nameTag != "" {
+			fb.key = tag
+		}
+
+		if def, ok := field.Tag.Lookup(TagDefault); ok {
+			fb.defaults = []string{def}
+		}
+
+		fb.parseField = field
+		if field.Type.Kind() == reflect.Ptr && !cs.isBindable(field.Type) {
+			fb.pointer = true
+			fb.parseField.Type = field.Type.Elem()
+		}
+
+		if cs.isMulti(fb.parseField.Type) {
+			fb.multi = true
+			fb.parseField.Type = fb.parseField.Type.Elem()
+		}
+
+		if !fb.file {
+			fb.parse = cs.parser(fb.parseField.Type)
+			if fb.parse == nil {
+				// It cannot be bound, which fails the registration of the handlers for the special fields, and the
+				// decoding of a form for the Body
+				plan.unbound = append(plan.unbound, field.Name)
+				continue
+			}
+		}
+		plan.fields = append(plan.fields, fb)
+	}
+	return plan
+}
+
+// bind binds the special field of the handler from the values returned by lookup for the keys of its fields, and
+// validates it afterwards
+func (p *bindingPlan) bind(handlerObject reflect.Value, lookup func(key string) []string) error {
+	obj := handlerObject.Field(p.index)
+
+	missing, err := p.bindFields(obj, lookup)
+	if err != nil {
+		return err
+	}
+	return validateBound(obj, p.place, missing)
+}
+
+// bindFields binds the fields of the struct, and returns the required fields that are missing
+func (p *bindingPlan) bindFields(obj reflect.Value, lookup func(key string) []string) (map[string]string, error) {
+	var missing map[string]string
+	for i := range p.fields {
+		fb := &p.fields[i]
+		if fb.file {
+			continue
+		}
+
+		ok, err := fb.bind(lookup(fb.key), p.place, obj.Field(fb.index))
+		if err != nil {
+			return nil, err
+		} else if !ok && fb.required {
+			if missing == nil {
+				missing = make(map[string]string)
+			}
+			missing[fb.field.Name] = "missing required value"
+		}
+	}
+	return missing, nil
+}
+
+// bind binds the field from the given values, or from the value in the default tag if there are none. Pointer
+// fields are left nil if there are no values. It returns whether the field is bound.
+func (fb *fieldBinding) bind(values []string, place string, val reflect.Value) (bool, error) {
+	if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+		if fb.defaults == nil {
+			// Do not fail right now, it is the job of validator
+			return false, nil
+		}
+		values = fb.defaults
+	}
+
+	if !fb.pointer {
+		return true, fb.bindValues(values, place, val)
+	}
+
+	ptr := reflect.New(fb.field.Type.Elem())
+	err := fb.bindValues(values, place, ptr.Elem())
+	if err != nil {
+		return false, err
+	}
+
+	val.Set(ptr)
+	return true, nil
+}
+
+// bindValues parses the values either to the elements of the field, or only the first one to the field itself. The
+// elements are reported with their index in the ParseError.
+func (fb *fieldBinding) bindValues(values []string, place string, val reflect.Value) error {
+	if !fb.multi {
+		return fb.parse(values[0], place, fb.parseField, &val)
+	}
+
+	values = splitValues(values, fb.sep)
+	if val.Kind() == reflect.Slice {
+		val.Set(reflect.MakeSlice(val.Type(), len(values), len(values)))
+	} else if len(values) > val.Len() {
+		return ParseError{
+			Place:     place,
+			FieldName: fb.field.Name,
+			Reason:    fmt.Sprintf("Expected at most %d values but got %d", val.Len(), len(values)),
+		}
+	}
+
+	for i, s := range values {
+		elem := val.Index(i)
+		err := fb.parse(s, place, fb.parseField, &elem)
+		if perr, ok := err.(ParseError); ok {
+			perr.FieldName = fmt.Sprintf("%s[%d]", fb.field.Name, i)
+			return perr
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package gongular
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindingBenchHandler struct {
+	Query struct {
+		Name   string `q:"name"`
+		Age    int    `q:"age" default:"18"`
+		Score  float64
+		Active bool
+		Tags   []string `q:"tag"`
+		IDs    []int    `q:"id" sep:","`
+		Limit  *int
+	}
+	Header struct {
+		RequestID string `header:"x-request-id"`
+	}
+}
+
+func (b *bindingBenchHandler) Handle(c *Context) error {
+	return nil
+}
+
+const bindingBenchQuery = "name=mustafa&age=30&Score=4.5&Active=true&tag=a&tag=b&id=1,2,3&Limit=10"
+
+func TestBindingPlan_Compile(t *testing.T) {
+	tip := reflect.TypeOf(bindingBenchHandler{})
+	cs := make(converters)
+
+	plan := cs.compileBinding(tip, FieldQuery, PlaceQuery, TagQuery)
+	require.NotNil(t, plan)
+	assert.Equal(t, 0, plan.index)
+	require.Len(t, plan.fields, 7)
+
+	assert.Equal(t, "age", plan.fields[1].key)
+	assert.Equal(t, []string{"18"}, plan.fields[1].defaults)
+	assert.True(t, plan.fields[4].multi)
+	assert.Equal(t, ",", plan.fields[5].sep)
+	assert.True(t, plan.fields[6].pointer)
+	assert.Equal(t, reflect.TypeOf(0), plan.fields[6].parseField.Type)
+
+	header := cs.compileBinding(tip, FieldHeader, PlaceHeader, TagHeader)
+	require.NotNil(t, header)
+	assert.Equal(t, "X-Request-Id", header.fields[0].key)
+
+	assert.Nil(t, cs.compileBinding(tip, FieldForm, PlaceForm, ""))
+}
+
+func TestBindingPlan_Bind(t *testing.T) {
+	hc, err := transformRequestHandler("/", http.MethodGet, newInjector(), newBinder(), &bindingBenchHandler{})
+	require.NoError(t, err)
+
+	values, err := url.ParseQuery(bindingBenchQuery)
+	require.NoError(t, err)
+
+	obj := reflect.New(hc.tip).Elem()
+	err = hc.queryPlan.bind(obj, func(key string) []string {
+		return values[key]
+	})
+	require.NoError(t, err)
+
+	h := obj.Addr().Interface().(*bindingBenchHandler)
+	assert.Equal(t, "mustafa", h.Query.Name)
+	assert.Equal(t, 30, h.Query.Age)
+	assert.Equal(t, []string{"a", "b"}, h.Query.Tags)
+	assert.Equal(t, []int{1, 2, 3}, h.Query.IDs)
+	require.NotNil(t, h.Query.Limit)
+	assert.Equal(t, 10, *h.Query.Limit)
+}
+
+// parseQueryReflective is how the Query field was bound before the plans, kept as a reference for the benchmarks. It
+// looks up the field, the tags and the parsers of each field for each request.
+func parseQueryReflective(obj reflect.Value, values url.Values) error {
+	query := obj.FieldByName(FieldQuery)
+	queryType := query.Type()
+
+	for i := 0; i < queryType.NumField(); i++ {
+		field := queryType.Field(i)
+
+		key := field.Name
+		if tag, ok := field.Tag.Lookup(TagQuery); ok {
+			key = tag
+		}
+
+		vals := values[key]
+		if len(vals) == 0 {
+			def, ok := field.Tag.Lookup(TagDefault)
+			if !ok {
+				// Do not fail right now, it is the job of validator
+				continue
+			}
+			vals = []string{def}
+		}
+
+		val := query.Field(i)
+		if val.Kind() == reflect.Ptr {
+			val.Set(reflect.New(field.Type.Elem()))
+			val = val.Elem()
+			field.Type = field.Type.Elem()
+		}
+
+		if val.Kind() != reflect.Slice {
+			err := simpleParser(val.Kind())(vals[0], PlaceQuery, field, &val)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		vals = splitValues(vals, field.Tag.Get(TagSeparator))
+		val.Set(reflect.MakeSlice(field.Type, len(vals), len(vals)))
+		for j, s := range vals {
+			elem := val.Index(j)
+			err := simpleParser(elem.Kind())(s, PlaceQuery, field, &elem)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return validateStruct(query, PlaceQuery)
+}
+
+func TestBindingPlan_Reflective(t *testing.T) {
+	hc, err := transformRequestHandler("/", http.MethodGet, newInjector(), newBinder(), &bindingBenchHandler{})
+	require.NoError(t, err)
+
+	values, err := url.ParseQuery(bindingBenchQuery)
+	require.NoError(t, err)
+
+	compiled := reflect.New(hc.tip).Elem()
+	require.NoError(t, hc.queryPlan.bind(compiled, func(key string) []string {
+		return values[key]
+	}))
+
+	reflective := reflect.New(hc.tip).Elem()
+	require.NoError(t, parseQueryReflective(reflective, values))
+	assert.Equal(t, compiled.Interface(), reflective.Interface())
+}
+
+func BenchmarkBinding_Uncompiled(b *testing.B) {
+	tip := reflect.TypeOf(bindingBenchHandler{})
+	values, _ := url.ParseQuery(bindingBenchQuery)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		obj := reflect.New(tip).Elem()
+		if err := parseQueryReflective(obj, values); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBinding_Compiled(b *testing.B) {
+	tip := reflect.TypeOf(bindingBenchHandler{})
+	cs := make(converters)
+	values, _ := url.ParseQuery(bindingBenchQuery)
+	lookup := func(key string) []string {
+		return values[key]
+	}
+
+	plan := cs.compileBinding(tip, FieldQuery, PlaceQuery, TagQuery)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		obj := reflect.New(tip).Elem()
+		if err := plan.bind(obj, lookup); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBinding_Request(b *testing.B) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &bindingBenchHandler{})
+
+	req := httptest.NewRequest(http.MethodGet, "/?"+bindingBenchQuery, nil)
+	req.Header.Set("X-Request-ID", "abc")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.GetHandler().ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
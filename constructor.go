@@ -0,0 +1,206 @@
+package gongular
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// constructor is a function provided with ProvideConstructor, whose parameters are resolved from the injector and
+// whose result is provided as a singleton
+type constructor struct {
+	fn     reflect.Value
+	tip    reflect.Type
+	key    string
+	params []constructorParam
+
+	// The injector the constructor is provided to, which resolves its parameters
+	injector *injector
+}
+
+// constructorParam is a parameter of a constructor, which is either a single dependency or an unnamed struct whose
+// exported fields are the dependencies
+type constructorParam struct {
+	tip    reflect.Type
+	fields []int
+	deps   []dependency
+}
+
+// dependency is the type and the key of a value needed by a constructor
+type dependency struct {
+	tip reflect.Type
+	key string
+}
+
+func (d dependency) String() string {
+	if d.key == "default" {
+		return d.tip.String()
+	}
+	return fmt.Sprintf("%s %q", d.tip, d.key)
+}
+
+// newConstructor analyzes the function, which must return a value and optionally an error
+func newConstructor(fn interface{}, key string) *constructor {
+	fnVal := reflect.ValueOf(fn)
+	fnType := fnVal.Type()
+	if fnType.Kind() != reflect.Func {
+		panic(fmt.Sprintf("the constructor must be a function, not %s", fnType))
+	}
+
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	switch {
+	case fnType.NumOut() == 1 && fnType.Out(0) != errorType:
+	case fnType.NumOut() == 2 && fnType.Out(1) == errorType:
+	default:
+		panic(fmt.Sprintf("the constructor %s must return a value and optionally an error", fnType))
+	}
+
+	ctor := &constructor{
+		fn:  fnVal,
+		tip: fnType.Out(0),
+		key: key,
+	}
+
+	for i := 0; i < fnType.NumIn(); i++ {
+		ctor.params = append(ctor.params, newConstructorParam(fnType.In(i)))
+	}
+	return ctor
+}
+
+// newConstructorParam resolves an unnamed struct field by field with their inject tags, and the other types as is
+func newConstructorParam(tip reflect.Type) constructorParam {
+	param := constructorParam{
+		tip: tip,
+	}
+
+	if tip.Kind() != reflect.Struct || tip.Name() != "" {
+		param.deps = []dependency{{tip: tip, key: "default"}}
+		return param
+	}
+
+	for i := 0; i < tip.NumField(); i++ {
+		field := tip.Field(i)
+		if field.PkgPath != "" || field.Tag.Get(TagInject) == "-" {
+			continue
+		}
+
+		key, ok := field.Tag.Lookup(TagInject)
+		if !ok {
+			key = "default"
+		}
+
+		param.fields = append(param.fields, i)
+		param.deps = append(param.deps, dependency{tip: field.Type, key: key})
+	}
+	return param
+}
+
+func (ctor *constructor) String() string {
+	return dependency{tip: ctor.tip, key: ctor.key}.String()
+}
+
+// call resolves the parameters of the constructor and calls it
+func (ctor *constructor) call(c *Context) (interface{}, error) {
+	args := make([]reflect.Value, len(ctor.params))
+	for i, param := range ctor.params {
+		values := make([]reflect.Value, len(param.deps))
+		for j, dep := range param.deps {
+			resolved, err := ctor.injector.resolve(dep.tip, dep.key)
+			if err != nil {
+				return nil, fmt.Errorf("The constructor of %s cannot get %s, %w", ctor, dep, err)
+			}
+
+			val, err := resolved.get(c)
+			if err != nil {
+				return nil, err
+			}
+			values[j] = val
+		}
+
+		if param.fields == nil {
+			args[i] = values[0]
+			continue
+		}
+
+		args[i] = reflect.New(param.tip).Elem()
+		for j, index := range param.fields {
+			args[i].Field(index).Set(values[j])
+		}
+	}
+
+	out := ctor.fn.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		return nil, out[1].Interface().(error)
+	}
+	return out[0].Interface(), nil
+}
+
+// check checks that the dependencies of the constructor are provided, and they do not depend on each other in a cycle
+func (ctor *constructor) check() error {
+	return ctor.visit(nil, make(map[*constructor]bool))
+}
+
+// checkConstructors checks all the provided constructors in the order they are provided, then the ones of the
+// child injectors
+func (inj *injector) checkConstructors() error {
+	checked := make(map[*constructor]bool)
+	for _, ctor := range inj.constructors {
+		err := ctor.visit(nil, checked)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, child := range inj.children {
+		err := child.checkConstructors()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// visit walks the dependencies of the constructor depth first, path is the constructors that lead to it
+func (ctor *constructor) visit(path []*constructor, checked map[*constructor]bool) error {
+	for i, visited := range path {
+		if visited != ctor {
+			continue
+		}
+
+		cycle := make([]string, 0, len(path)-i+1)
+		for _, c := range path[i:] {
+			cycle = append(cycle, c.String())
+		}
+		return DependencyCycleError{Cycle: append(cycle, ctor.String())}
+	}
+
+	if checked[ctor] {
+		return nil
+	}
+
+	path = append(path, ctor)
+	for _, param := range ctor.params {
+		for _, dep := range param.deps {
+			resolved, err := ctor.injector.resolve(dep.tip, dep.key)
+			if err != nil {
+				return fmt.Errorf("The constructor of %s cannot get %s, %w", ctor, dep, err)
+			}
+
+			p := resolved.custom
+			if p == nil {
+				continue
+			} else if p.scope == ScopeRequest {
+				return fmt.Errorf("The constructor of %s cannot depend on %s, which is created for each request", ctor, dep)
+			} else if p.constructor == nil {
+				continue
+			}
+
+			err = p.constructor.visit(path, checked)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	checked[ctor] = true
+	return nil
+}
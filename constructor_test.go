@@ -0,0 +1,142 @@
+package gongular
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctorConfig struct {
+	Name string
+}
+
+type ctorRepository struct {
+	Config  *ctorConfig
+	Replica *ctorConfig
+}
+
+type ctorService struct {
+	Repository *ctorRepository
+}
+
+type ctorHandler struct {
+	Service *ctorService
+}
+
+func (h *ctorHandler) Handle(c *Context) error {
+	repo := h.Service.Repository
+	c.SetBody(fmt.Sprintf("%s:%s", repo.Config.Name, repo.Replica.Name))
+	return nil
+}
+
+func TestProvideConstructor(t *testing.T) {
+	e := newEngineTest()
+	e.Provide(&ctorConfig{Name: "primary"})
+	e.ProvideWithKey("replica", &ctorConfig{Name: "replica"})
+
+	calls := 0
+	e.ProvideConstructor(func(repo *ctorRepository) (*ctorService, error) {
+		calls++
+		return &ctorService{Repository: repo}, nil
+	})
+	e.ProvideConstructor(func(deps struct {
+		Config  *ctorConfig
+		Replica *ctorConfig `inject:"replica"`
+	}) *ctorRepository {
+		return &ctorRepository{Config: deps.Config, Replica: deps.Replica}
+	})
+
+	e.GetRouter().GET("/", &ctorHandler{})
+	require.NoError(t, e.Validate())
+
+	for i := 0; i < 2; i++ {
+		resp, content := get(t, e, "/")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, `"primary:replica"`, content)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestProvideConstructor_Error(t *testing.T) {
+	e := newEngineTest()
+	calls := 0
+	e.ProvideConstructor(func() (*ctorRepository, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("not ready")
+		}
+		return &ctorRepository{Config: &ctorConfig{}, Replica: &ctorConfig{}}, nil
+	})
+	e.ProvideConstructor(func(repo *ctorRepository) *ctorService {
+		return &ctorService{Repository: repo}
+	})
+	e.GetRouter().GET("/", &ctorHandler{})
+
+	resp, _ := get(t, e, "/")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	resp, _ = get(t, e, "/")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 2, calls)
+}
+
+type ctorA struct{}
+type ctorB struct{}
+type ctorC struct{}
+
+type ctorCycleHandler struct {
+	A *ctorA
+}
+
+func (h *ctorCycleHandler) Handle(c *Context) error {
+	return nil
+}
+
+func TestProvideConstructor_Cycle(t *testing.T) {
+	e := newEngineTest()
+	e.ProvideConstructor(func(b *ctorB) *ctorA { return &ctorA{} })
+	e.ProvideConstructor(func(deps struct {
+		C *ctorC `inject:"other"`
+	}) *ctorB {
+		return &ctorB{}
+	})
+	e.ProvideConstructorWithKey("other", func(a *ctorA) *ctorC { return &ctorC{} })
+
+	err := e.Validate()
+	var cycleErr DependencyCycleError
+	require.True(t, errors.As(err, &cycleErr))
+	assert.Equal(t, []string{"*gongular.ctorA", "*gongular.ctorB", `*gongular.ctorC "other"`, "*gongular.ctorA"}, cycleErr.Cycle)
+	assert.EqualError(t, err, `Dependency cycle: *gongular.ctorA -> *gongular.ctorB -> *gongular.ctorC "other" -> *gongular.ctorA`)
+
+	_, err = transformRequestHandler("/", http.MethodGet, e.injector, newBinder(), &ctorCycleHandler{})
+	require.True(t, errors.As(err, &cycleErr))
+	assert.Contains(t, err.Error(), "The field A of")
+}
+
+func TestProvideConstructor_Dependencies(t *testing.T) {
+	inj := newInjector()
+	inj.ProvideConstructor(func(repo *ctorRepository) *ctorService { return nil }, "default")
+	err := inj.checkConstructors()
+	require.True(t, errors.Is(err, ErrNoSuchDependency))
+	assert.Contains(t, err.Error(), "*gongular.ctorRepository")
+
+	inj.ProvideCustom(&ctorRepository{}, func(c *Context) (interface{}, error) {
+		return &ctorRepository{}, nil
+	}, "default")
+	err = inj.checkConstructors()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "created for each request")
+
+	inj.ProvideScoped(&ctorRepository{}, func(c *Context) (interface{}, error) {
+		return &ctorRepository{}, nil
+	}, "default", ScopeSingleton)
+	assert.NoError(t, inj.checkConstructors())
+
+	assert.Panics(t, func() { inj.ProvideConstructor(&ctorService{}, "default") })
+	assert.Panics(t, func() { inj.ProvideConstructor(func() error { return nil }, "default") })
+	assert.Panics(t, func() { inj.ProvideConstructor(func() (*ctorService, bool) { return nil, false }, "default") })
+}
@@ -1,9 +1,13 @@
 package gongular
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"strings"
+	"time"
 
 	"reflect"
 
@@ -22,14 +26,28 @@ type Context struct {
 	stopChain bool
 	params    httprouter.Params
 	path      string
+	upgraded  bool
+	encoders  *encoders
+	files     []multipart.File
+
+	// The finalizers of the provided values in the order of their creation
+	finalizers []Finalizer
+
+	// Whether the body and the status are set from a Response field, so that a later handler can replace them
+	responseBody   bool
+	responseStatus bool
 
 	injectCache map[reflect.Type]map[string]interface{}
 }
 
+// The Context can be given to the functions that need a context.Context
+var _ context.Context = (*Context)(nil)
+
 // ContextFromRequest creates a new Context object from a valid  HTTP Request.
-func contextFromRequest(path string, w http.ResponseWriter, r *http.Request, params httprouter.Params, logger *log.Logger) *Context {
+func contextFromRequest(path string, w http.ResponseWriter, r *http.Request, params httprouter.Params, logger *log.Logger, enc *encoders) *Context {
 	return &Context{
 		path:        path,
+		encoders:    enc,
 		r:           r,
 		w:           w,
 		headers:     make(map[string]string),
@@ -39,6 +57,45 @@ func contextFromRequest(path string, w http.ResponseWriter, r *http.Request, par
 	}
 }
 
+// Deadline returns the deadline of the request context, which is set by the timeout of the route
+func (c *Context) Deadline() (time.Time, bool) {
+	return c.r.Context().Deadline()
+}
+
+// Done returns a channel that is closed when the request is canceled or its timeout is exceeded
+func (c *Context) Done() <-chan struct{} {
+	return c.r.Context().Done()
+}
+
+// Err returns why the request context is done, or nil if it is not
+func (c *Context) Err() error {
+	return c.r.Context().Err()
+}
+
+// Value returns the value of the request context for the key, so that the Context can be given to the functions
+// that need a context.Context
+func (c *Context) Value(key interface{}) interface{} {
+	return c.r.Context().Value(key)
+}
+
+// detached returns a copy of the context that is not canceled with the request and does not write to its response,
+// for creating the values that outlive the request
+func (c *Context) detached() *Context {
+	d := &Context{
+		path:        c.path,
+		params:      c.params,
+		logger:      c.logger,
+		encoders:    c.encoders,
+		headers:     make(map[string]string),
+		injectCache: make(map[reflect.Type]map[string]interface{}),
+	}
+
+	if c.r != nil {
+		d.r = c.r.WithContext(context.Background())
+	}
+	return d
+}
+
 // Params returns the URL parameters of the request
 func (c *Context) Params() httprouter.Params {
 	return c.params
@@ -51,9 +108,10 @@ func (c *Context) Request() *http.Request {
 
 // Status sets the response code for a request. It generates a warning if it has been tried to set multiple times.
 func (c *Context) Status(status int) {
-	// Meaning no status written before
-	if c.status == 0 {
+	// Meaning no status written before, or it is set from a Response field
+	if c.status == 0 || c.responseStatus {
 		c.status = status
+		c.responseStatus = false
 	} else {
 		c.logger.Printf("Tried to set request status '%d' but it was previously set to '%d'\n", status, c.status)
 	}
@@ -67,6 +125,7 @@ func (c *Context) Logger() *log.Logger {
 // MustStatus overrides the status
 func (c *Context) MustStatus(status int) {
 	c.status = status
+	c.responseStatus = false
 }
 
 // StopChain marks the context as chain is going to be stopped, meaning no other handlers will be executed.
@@ -82,6 +141,7 @@ func (c *Context) Header(key, value string) {
 // SetBody sets the given interface which will be written
 func (c *Context) SetBody(v interface{}) {
 	c.body = v
+	c.responseBody = false
 }
 
 // Fail stops the chain with a status code and an object
@@ -111,13 +171,45 @@ func (c *Context) Finalize() int {
 			return bytes
 		}
 
-		b, err := json.MarshalIndent(c.body, "", "  ")
+		contentTypes := c.acceptedContentTypes()
+		if len(contentTypes) == 0 {
+			c.status = http.StatusNotAcceptable
+			c.w.Header().Set("Content-type", "text/plain; charset=utf-8")
+			c.w.WriteHeader(c.status)
+
+			bytes, err := c.w.Write([]byte(http.StatusText(c.status)))
+			if err != nil {
+				c.logger.Println(err)
+			}
+			return bytes
+		}
+
+		contentType, b, err := c.encoders.encode(c.body, contentTypes, c.logger)
 		if err != nil {
 			c.logger.Println("Could not serialize the response", err)
-			return -1
+
+			// The status is written anyway, so that the client does not get an empty successful response. It is the
+			// fault of the server only if JSON is accepted as well, which is expected to encode any body.
+			c.status = http.StatusNotAcceptable
+			for _, accepted := range contentTypes {
+				if accepted == "application/json" {
+					c.status = http.StatusInternalServerError
+				}
+			}
+			c.w.Header().Set("Content-type", "text/plain; charset=utf-8")
+			c.w.WriteHeader(c.status)
+
+			bytes, err := c.w.Write([]byte(http.StatusText(c.status)))
+			if err != nil {
+				c.logger.Println(err)
+			}
+			return bytes
 		}
 
-		c.w.Header().Set("Content-type", "application/json")
+		if strings.HasPrefix(contentType, "text/") {
+			contentType += "; charset=utf-8"
+		}
+		c.w.Header().Set("Content-type", contentType)
 		c.w.WriteHeader(c.status)
 
 		bytes, err := c.w.Write(b)
@@ -131,6 +223,20 @@ func (c *Context) Finalize() int {
 	return 0
 }
 
+// acceptedContentTypes returns the content types of the encoders accepted by the request, the most preferred first.
+// The built-in encoders are used if the context is not bound to an engine.
+func (c *Context) acceptedContentTypes() []string {
+	if c.encoders == nil {
+		c.encoders = newEncoders()
+	}
+
+	accept := ""
+	if c.r != nil {
+		accept = c.r.Header.Get("Accept")
+	}
+	return c.encoders.negotiate(accept)
+}
+
 func (c *Context) getCachedInjection(tip reflect.Type, key string) (interface{}, bool) {
 	if m, ok := c.injectCache[tip]; ok {
 		val, ok2 := m[key]
@@ -146,3 +252,28 @@ func (c *Context) putCachedInjection(tip reflect.Type, key string, val interface
 
 	c.injectCache[tip][key] = val
 }
+
+// runFinalizers calls the finalizers of the provided values in the reverse order of their creation with the error
+// that ended the handler chain, and returns the errors they return. A panicking finalizer does not stop the others,
+// its panic is returned as an error.
+func (c *Context) runFinalizers(chainErr error) []error {
+	var errs []error
+	for i := len(c.finalizers) - 1; i >= 0; i-- {
+		err := callFinalizer(c.finalizers[i], chainErr)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.finalizers = nil
+	return errs
+}
+
+// callFinalizer calls the finalizer, turning its panic into an error
+func callFinalizer(fn Finalizer, chainErr error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+	return fn(chainErr)
+}
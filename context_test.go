@@ -1,12 +1,17 @@
 package gongular
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestContext_Fail(t *testing.T) {
@@ -49,3 +54,129 @@ func TestContext_StatusTwice(t *testing.T) {
 	c.Status(http.StatusInternalServerError)
 	assert.Equal(t, http.StatusTeapot, c.status)
 }
+
+type contextKey struct{}
+
+type deadlineHandler struct {
+	Query struct {
+		Wait bool
+	}
+}
+
+func (d *deadlineHandler) Handle(c *Context) error {
+	if d.Query.Wait {
+		<-c.Done()
+		return c.Err()
+	}
+
+	deadline, ok := c.Deadline()
+	c.SetBody(map[string]interface{}{
+		"Value":    c.Value(contextKey{}),
+		"Deadline": ok && time.Until(deadline) > 0,
+	})
+	return nil
+}
+
+type deadlineNextHandler struct{}
+
+func (d *deadlineNextHandler) Handle(c *Context) error {
+	c.SetBody("next")
+	return nil
+}
+
+type deadlineIgnoringHandler struct{}
+
+func (d *deadlineIgnoringHandler) Handle(c *Context) error {
+	<-c.Done()
+	c.SetBody("late")
+	return nil
+}
+
+func TestContext_Context(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &deadlineHandler{})
+	e.GetRouter().WithTimeout(time.Minute).GET("/timeout", &deadlineHandler{})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(context.WithValue(req.Context(), contextKey{}, "hello"))
+	_, content := serve(t, e, req)
+	assert.JSONEq(t, `{"Value": "hello", "Deadline": false}`, content)
+
+	_, content = get(t, e, "/timeout")
+	assert.JSONEq(t, `{"Value": null, "Deadline": true}`, content)
+}
+
+func TestContext_Timeout(t *testing.T) {
+	e := newEngineTest()
+	var stat RouteStat
+	e.SetRouteCallback(func(s RouteStat) {
+		stat = s
+	})
+
+	g := e.GetRouter().Group("/api").WithTimeout(10 * time.Millisecond)
+	g.GET("/wait", &deadlineHandler{}, &deadlineNextHandler{})
+	g.Group("/v2").GET("/wait", &deadlineHandler{}, &deadlineNextHandler{})
+
+	for _, path := range []string{"/api/wait?Wait=true", "/api/v2/wait?Wait=true"} {
+		resp, content := get(t, e, path)
+		assert.Equal(t, http.StatusGatewayTimeout, resp.Code, path)
+		assert.Equal(t, `"Gateway Timeout"`, content, path)
+
+		var deadlineErr DeadlineError
+		require.True(t, errors.As(stat.Handlers[0].Error, &deadlineErr), path)
+		assert.True(t, errors.Is(deadlineErr, context.DeadlineExceeded))
+		assert.True(t, stat.Handlers[0].StopChain)
+		assert.Empty(t, stat.Handlers[1].FuncName)
+	}
+
+	resp, content := get(t, e, "/api/wait")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"next"`, content)
+}
+
+type deadlineFailHandler struct{}
+
+func (d *deadlineFailHandler) Handle(c *Context) error {
+	<-c.Done()
+	c.Fail(http.StatusUnauthorized, "unauthorized")
+	return nil
+}
+
+func TestContext_TimeoutIgnored(t *testing.T) {
+	e := newEngineTest()
+	var stat RouteStat
+	e.SetRouteCallback(func(s RouteStat) {
+		stat = s
+	})
+	e.GetRouter().WithTimeout(10*time.Millisecond).GET("/", &deadlineNextHandler{}, &deadlineIgnoringHandler{})
+
+	// The last handler succeeds after the deadline, there is no handler left to notice it
+	resp, content := get(t, e, "/")
+	assert.Equal(t, http.StatusGatewayTimeout, resp.Code)
+	assert.Equal(t, `"Gateway Timeout"`, content)
+	assert.NoError(t, stat.Handlers[1].Error)
+
+	// The response of a handler that stops the chain is kept
+	e.GetRouter().WithTimeout(10*time.Millisecond).GET("/fail", &deadlineFailHandler{})
+	resp, content = get(t, e, "/fail")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, `"unauthorized"`, content)
+}
+
+func TestContext_Canceled(t *testing.T) {
+	e := newEngineTest()
+	var stat RouteStat
+	e.SetRouteCallback(func(s RouteStat) {
+		stat = s
+	})
+	e.GetRouter().GET("/", &deadlineHandler{})
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(reqCtx)
+
+	resp, _ := serve(t, e, req)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.True(t, errors.Is(stat.Handlers[0].Error, context.Canceled))
+	assert.Equal(t, time.Duration(0), stat.Handlers[0].Duration)
+}
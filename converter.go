@@ -0,0 +1,180 @@
+package gongular
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ConverterFunc converts a string in the request, i.e. a query parameter, to a value of the type it is registered for
+type ConverterFunc func(s string) (interface{}, error)
+
+// converters holds the custom ConverterFunc registered for the types
+type converters map[reflect.Type]ConverterFunc
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	uploadedFileType    = reflect.TypeOf(&UploadedFile{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// RegisterConverter registers a ConverterFunc for the type of the given value, so that the fields of that type can
+// be bound from Param, Query, Header, Cookie and Form. It overrides the built-in conversions for that type.
+func (e *Engine) RegisterConverter(value interface{}, fn ConverterFunc) {
+	e.binder.converters[reflect.TypeOf(value)] = fn
+}
+
+// isBindable returns whether a string can be converted to the given type
+func (cs converters) isBindable(tip reflect.Type) bool {
+	if _, ok := cs[tip]; ok {
+		return true
+	}
+
+	if tip == timeType || tip == durationType || reflect.PtrTo(tip).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch tip.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isMulti returns whether the field is bound from multiple values, where each of them is converted to an element
+func (cs converters) isMulti(tip reflect.Type) bool {
+	return isMultiValue(tip) && !cs.isBindable(tip)
+}
+
+// checkBindable checks whether all the fields of the struct in the special field can be bound, multi is whether
+// slices and arrays are allowed
+func (cs converters) checkBindable(handlerElem reflect.Type, fieldName string, multi bool) error {
+	special, ok := handlerElem.FieldByName(fieldName)
+	if !ok {
+		return nil
+	}
+
+	tip := special.Type
+	for i := 0; i < tip.NumField(); i++ {
+		field := tip.Field(i)
+
+		fieldType := field.Type
+		if fieldName == FieldForm && (isFileField(fieldType) || fieldType == multipartReaderType) {
+			continue
+		}
+
+		// Pointers are left nil when the value is missing
+		if fieldType.Kind() == reflect.Ptr && !cs.isBindable(fieldType) {
+			fieldType = fieldType.Elem()
+		}
+
+		if multi && cs.isMulti(fieldType) {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct && !cs.isBindable(fieldType) {
+			return fmt.Errorf("%s field %s is a nested struct, %s should be a flat struct", fieldName, field.Name, fieldName)
+		} else if !cs.isBindable(fieldType) {
+			return fmt.Errorf("%s field %s has type %s which cannot be bound, register a converter for it",
+				fieldName, field.Name, field.Type)
+		}
+	}
+	return nil
+}
+
+// parseFunc converts the string to the type of the field, and sets it to val
+type parseFunc func(s string, place string, field reflect.StructField, val *reflect.Value) error
+
+// parser returns the parseFunc for the type by using the custom converters, the time types and
+// encoding.TextUnmarshaler before falling back to the simple types, or nil if it cannot be bound
+func (cs converters) parser(tip reflect.Type) parseFunc {
+	if fn, ok := cs[tip]; ok {
+		return func(s string, place string, field reflect.StructField, val *reflect.Value) error {
+			return convert(fn, s, place, field, val)
+		}
+	}
+
+	switch {
+	case tip == timeType:
+		return parseTime
+	case tip == durationType:
+		return parseDuration
+	case reflect.PtrTo(tip).Implements(textUnmarshalerType):
+		return parseText
+	}
+
+	return simpleParser(tip.Kind())
+}
+
+func convert(fn ConverterFunc, s string, place string, field reflect.StructField, val *reflect.Value) error {
+	v, err := fn(s)
+	if err != nil {
+		return ParseError{
+			Place:     place,
+			FieldName: field.Name,
+			Reason:    err.Error(),
+		}
+	}
+
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !rv.Type().AssignableTo(field.Type) {
+		return ParseError{
+			Place:     place,
+			FieldName: field.Name,
+			Reason:    fmt.Sprintf("The converter returned %T which is not assignable to %s", v, field.Type),
+		}
+	}
+	val.Set(rv)
+	return nil
+}
+
+func parseTime(s string, place string, field reflect.StructField, val *reflect.Value) error {
+	layout := time.RFC3339
+	if tag, ok := field.Tag.Lookup(TagLayout); ok {
+		layout = tag
+	}
+
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return ParseError{
+			Place:     place,
+			FieldName: field.Name,
+			Reason:    fmt.Sprintf("The '%s' is not a time in the layout '%s'", s, layout),
+		}
+	}
+
+	val.Set(reflect.ValueOf(t))
+	return nil
+}
+
+func parseDuration(s string, place string, field reflect.StructField, val *reflect.Value) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return ParseError{
+			Place:     place,
+			FieldName: field.Name,
+			Reason:    fmt.Sprintf("The '%s' is not a duration", s),
+		}
+	}
+
+	val.SetInt(int64(d))
+	return nil
+}
+
+func parseText(s string, place string, field reflect.StructField, val *reflect.Value) error {
+	u := val.Addr().Interface().(encoding.TextUnmarshaler)
+	err := u.UnmarshalText([]byte(s))
+	if err != nil {
+		return ParseError{
+			Place:     place,
+			FieldName: field.Name,
+			Reason:    err.Error(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package gongular
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type timeQueryHandler struct {
+	Query struct {
+		Since   time.Time
+		Day     time.Time `layout:"2006-01-02"`
+		Timeout time.Duration
+		IP      net.IP
+		Days    []time.Time `layout:"2006-01-02" sep:","`
+	}
+}
+
+func (h *timeQueryHandler) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%s|%s|%s|%s|%d",
+		h.Query.Since.UTC().Format(time.RFC3339), h.Query.Day.Format("Jan 2"), h.Query.Timeout, h.Query.IP,
+		len(h.Query.Days)))
+	return nil
+}
+
+func TestConverter_BuiltIn(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &timeQueryHandler{})
+
+	resp, content := get(t, e, "/?Since=2020-01-02T03:04:05%2B01:00&Day=2020-05-06&Timeout=1m30s&IP=10.0.0.1&Days=2020-01-01,2020-01-02")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"2020-01-02T02:04:05Z|May 6|1m30s|10.0.0.1|2"`, content)
+}
+
+func TestConverter_BuiltInErrors(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &timeQueryHandler{})
+
+	for _, query := range []string{"Since=yesterday", "Day=2020-13-01", "Timeout=long", "IP=999.1.1.1", "Days=2020-01-01,x"} {
+		resp, content := get(t, e, "/?"+query)
+		assert.Equal(t, http.StatusBadRequest, resp.Code, query)
+		assert.Contains(t, content, "ParseError", query)
+	}
+}
+
+type upperString string
+
+type customConverterHandler struct {
+	Param struct {
+		Name upperString
+	}
+}
+
+func (h *customConverterHandler) Handle(c *Context) error {
+	c.SetBody(string(h.Param.Name))
+	return nil
+}
+
+func TestConverter_Custom(t *testing.T) {
+	e := newEngineTest()
+	e.RegisterConverter(upperString(""), func(s string) (interface{}, error) {
+		if s == "nobody" {
+			return nil, errors.New("nobody is not allowed")
+		}
+		return upperString(strings.ToUpper(s)), nil
+	})
+	e.GetRouter().GET("/:Name", &customConverterHandler{})
+
+	resp, content := get(t, e, "/mustafa")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"MUSTAFA"`, content)
+
+	resp2, content2 := get(t, e, "/nobody")
+	assert.Equal(t, http.StatusBadRequest, resp2.Code)
+	assert.Contains(t, content2, "nobody is not allowed")
+}
+
+type unbindableQueryHandler struct {
+	Query struct {
+		Filter map[string]string
+	}
+}
+
+func (h *unbindableQueryHandler) Handle(c *Context) error {
+	return nil
+}
+
+type unbindableParamSliceHandler struct {
+	Param struct {
+		IDs []int
+	}
+}
+
+func (h *unbindableParamSliceHandler) Handle(c *Context) error {
+	return nil
+}
+
+func TestConverter_RejectUnbindable(t *testing.T) {
+	_, err := transformRequestHandler("/", http.MethodGet, newInjector(), newBinder(), &unbindableQueryHandler{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Filter")
+
+	_, err = transformRequestHandler("/:IDs", http.MethodGet, newInjector(), newBinder(), &unbindableParamSliceHandler{})
+	assert.Error(t, err)
+
+	b := newBinder()
+	b.converters[reflect.TypeOf(map[string]string{})] = func(s string) (interface{}, error) {
+		return map[string]string{"q": s}, nil
+	}
+	_, err = transformRequestHandler("/", http.MethodGet, newInjector(), b, &unbindableQueryHandler{})
+	assert.NoError(t, err)
+}
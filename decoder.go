@@ -0,0 +1,159 @@
+package gongular
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// BodyDecoder decodes the request body into the Body field of a handler, v is a pointer to it
+type BodyDecoder func(body io.Reader, v interface{}) error
+
+// binder holds the engine wide configuration for binding the requests to the handlers
+type binder struct {
+	converters converters
+	decoders   map[string]BodyDecoder
+	strictJSON bool
+	maxMemory  int64
+
+	// The compiled plans of the structs decoded from url encoded forms, by their types
+	formPlans sync.Map
+}
+
+// newBinder creates a binder with the built-in decoders
+func newBinder() *binder {
+	b := &binder{
+		converters: make(converters),
+		decoders:   make(map[string]BodyDecoder),
+		maxMemory:  defaultMaxMemory,
+	}
+
+	b.decoders["application/json"] = b.decodeJSON
+	b.decoders["application/xml"] = decodeXML
+	b.decoders["text/xml"] = decodeXML
+	b.decoders["application/yaml"] = decodeYAML
+	b.decoders["application/x-yaml"] = decodeYAML
+	b.decoders["text/yaml"] = decodeYAML
+	b.decoders["application/msgpack"] = decodeMsgpack
+	b.decoders["application/x-msgpack"] = decodeMsgpack
+	b.decoders["application/vnd.msgpack"] = decodeMsgpack
+	b.decoders["application/x-www-form-urlencoded"] = b.decodeForm
+	return b
+}
+
+// RegisterDecoder registers a BodyDecoder for the given content type, i.e. "application/json", which is used for
+// filling the Body field of the handlers. It overrides the built-in decoder for that content type if there is any.
+func (e *Engine) RegisterDecoder(contentType string, d BodyDecoder) {
+	e.binder.decoders[strings.ToLower(contentType)] = d
+}
+
+// decoder returns the decoder for the Content-Type header of a request, JSON is assumed if it is missing
+func (b *binder) decoder(contentType string) (BodyDecoder, error) {
+	if contentType == "" {
+		return b.decoders["application/json"], nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, UnsupportedMediaTypeError{ContentType: contentType}
+	}
+
+	if d, ok := b.decoders[mediaType]; ok {
+		return d, nil
+	}
+
+	// Structured syntax suffixes, i.e. application/vnd.api+json
+	if strings.HasSuffix(mediaType, "+json") {
+		return b.decoders["application/json"], nil
+	} else if strings.HasSuffix(mediaType, "+xml") {
+		return b.decoders["application/xml"], nil
+	}
+
+	return nil, UnsupportedMediaTypeError{ContentType: mediaType}
+}
+
+// decodeJSON decodes the JSON body, rejecting the unknown fields and the trailing data if it is strict
+func (b *binder) decodeJSON(body io.Reader, v interface{}) error {
+	dec := json.NewDecoder(body)
+	if b.strictJSON {
+		dec.DisallowUnknownFields()
+	}
+
+	err := dec.Decode(v)
+	if err != nil || !b.strictJSON {
+		return err
+	}
+
+	var extra json.RawMessage
+	if err := dec.Decode(&extra); err != io.EOF {
+		return errors.New("unexpected data after the JSON value")
+	}
+	return nil
+}
+
+func decodeXML(body io.Reader, v interface{}) error {
+	return xml.NewDecoder(body).Decode(v)
+}
+
+func decodeYAML(body io.Reader, v interface{}) error {
+	return yaml.NewDecoder(body).Decode(v)
+}
+
+func decodeMsgpack(body io.Reader, v interface{}) error {
+	return msgpack.NewDecoder(body).Decode(v)
+}
+
+// decodeForm fills a flat struct from a url encoded form, in the same way with the Form field except the files. A
+// struct with a field that cannot be bound from a form, i.e. a nested struct, is not decoded instead of leaving the
+// field empty.
+func (b *binder) decodeForm(body io.Reader, v interface{}) error {
+	obj := reflect.ValueOf(v).Elem()
+	if obj.Kind() != reflect.Struct {
+		return errors.New("only structs can be decoded from a form")
+	}
+
+	plan := b.formPlan(obj.Type())
+	if len(plan.unbound) > 0 {
+		return ParseError{
+			Place:     PlaceBody,
+			FieldName: plan.unbound[0],
+			Reason:    "The field cannot be decoded from a form",
+		}
+	}
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	values, err := url.ParseQuery(string(content))
+	if err != nil {
+		return err
+	}
+
+	// The body is validated afterwards, so the missing fields are not reported here
+	_, err = plan.bindFields(obj, func(key string) []string {
+		return values[key]
+	})
+	return err
+}
+
+// formPlan returns the plan of the struct decoded from a form, which is compiled when it is decoded the first time
+func (b *binder) formPlan(tip reflect.Type) *bindingPlan {
+	if plan, ok := b.formPlans.Load(tip); ok {
+		return plan.(*bindingPlan)
+	}
+
+	plan, _ := b.formPlans.LoadOrStore(tip, b.converters.compileStruct(tip, PlaceBody, ""))
+	return plan.(*bindingPlan)
+}
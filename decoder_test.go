@@ -0,0 +1,146 @@
+package gongular
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type decoderHandler struct {
+	Body struct {
+		Name string   `yaml:"Name"`
+		Age  int      `yaml:"Age" valid:"range(0|150)"`
+		Tags []string `yaml:"Tags"`
+	}
+}
+
+func (d *decoderHandler) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%s:%d:%v", d.Body.Name, d.Body.Age, d.Body.Tags))
+	return nil
+}
+
+func postWithContentType(t *testing.T, e *Engine, contentType string, body io.Reader) (*httptest.ResponseRecorder, string) {
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set("Content-Type", contentType)
+	return serve(t, e, req)
+}
+
+func TestDecoder_BuiltIn(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/", &decoderHandler{})
+
+	packed, err := msgpack.Marshal(map[string]interface{}{"Name": "ayse", "Age": 4, "Tags": []string{"m"}})
+	require.NoError(t, err)
+
+	cases := map[string]io.Reader{
+		"application/json; charset=utf-8":   strings.NewReader(`{"Name": "ayse", "Age": 4, "Tags": ["m"]}`),
+		"application/vnd.api+json":          strings.NewReader(`{"Name": "ayse", "Age": 4, "Tags": ["m"]}`),
+		"application/xml":                   strings.NewReader(`<Body><Name>ayse</Name><Age>4</Age><Tags>m</Tags></Body>`),
+		"application/yaml":                  strings.NewReader("Name: ayse\nAge: 4\nTags: [m]\n"),
+		"application/msgpack":               bytes.NewReader(packed),
+		"application/x-www-form-urlencoded": strings.NewReader("Name=ayse&Age=4&Tags=m"),
+	}
+
+	for contentType, body := range cases {
+		resp, content := postWithContentType(t, e, contentType, body)
+		assert.Equal(t, http.StatusOK, resp.Code, contentType)
+		assert.Equal(t, `"ayse:4:[m]"`, content, contentType)
+	}
+}
+
+func TestDecoder_FormPlanCached(t *testing.T) {
+	b := newBinder()
+	type form struct {
+		Name string
+		Age  int
+	}
+
+	var f form
+	require.NoError(t, b.decodeForm(strings.NewReader("Name=ayse&Age=4"), &f))
+	plan, ok := b.formPlans.Load(reflect.TypeOf(f))
+	require.True(t, ok)
+
+	// The second request uses the same plan
+	require.NoError(t, b.decodeForm(strings.NewReader("Name=zeynep&Age=3"), &f))
+	assert.Equal(t, form{Name: "zeynep", Age: 3}, f)
+	assert.Same(t, plan, b.formPlan(reflect.TypeOf(f)))
+}
+
+type decoderNestedHandler struct {
+	Body struct {
+		Name  string
+		Inner struct {
+			X int
+		}
+	}
+}
+
+func (d *decoderNestedHandler) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%s:%d", d.Body.Name, d.Body.Inner.X))
+	return nil
+}
+
+func TestDecoder_FormUnbound(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/", &decoderNestedHandler{})
+
+	// The nested struct is not left empty silently
+	resp, content := postWithContentType(t, e, "application/x-www-form-urlencoded", strings.NewReader("Name=x&Inner.X=3"))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, content, `"FieldName": "Inner"`)
+
+	resp, content = postWithContentType(t, e, "application/json", strings.NewReader(`{"Name": "x", "Inner": {"X": 3}}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"x:3"`, content)
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/", &decoderHandler{})
+
+	resp, content := postWithContentType(t, e, "application/pdf", strings.NewReader("%PDF"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, resp.Code)
+	assert.Contains(t, content, "application/pdf")
+
+	resp2, content2 := postWithContentType(t, e, "application/x-www-form-urlencoded", strings.NewReader("Age=old"))
+	assert.Equal(t, http.StatusBadRequest, resp2.Code)
+	assert.Contains(t, content2, `"FieldName": "Age"`)
+
+	resp3, content3 := postWithContentType(t, e, "application/xml", strings.NewReader(`<Body><Age>200</Age></Body>`))
+	assert.Equal(t, http.StatusBadRequest, resp3.Code)
+	assert.Contains(t, content3, "ValidationError")
+}
+
+func TestDecoder_Custom(t *testing.T) {
+	e := newEngineTest()
+	e.RegisterDecoder("text/csv", func(body io.Reader, v interface{}) error {
+		record, err := csv.NewReader(body).Read()
+		if err != nil {
+			return err
+		}
+
+		b := v.(*struct {
+			Name string   `yaml:"Name"`
+			Age  int      `yaml:"Age" valid:"range(0|150)"`
+			Tags []string `yaml:"Tags"`
+		})
+		b.Name = record[0]
+		b.Tags = record[1:]
+		return nil
+	})
+	e.GetRouter().POST("/", &decoderHandler{})
+
+	resp, content := postWithContentType(t, e, "text/csv", strings.NewReader("ali,x,y\n"))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"ali:0:[x y]"`, content)
+}
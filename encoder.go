@@ -0,0 +1,194 @@
+package gongular
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// BodyEncoder serializes the response body set by the handlers
+type BodyEncoder func(v interface{}) ([]byte, error)
+
+// encoders holds the registered encoders in the order of their registration, the first one is used when the client
+// accepts anything
+type encoders struct {
+	contentTypes []string
+	encoders     map[string]BodyEncoder
+	compactJSON  bool
+}
+
+// newEncoders creates the encoders with JSON only, the others must be registered so that the responses of the
+// existing APIs do not change with the Accept header
+func newEncoders() *encoders {
+	enc := &encoders{
+		encoders: make(map[string]BodyEncoder),
+	}
+
+	enc.register("application/json", enc.encodeJSON)
+	return enc
+}
+
+// RegisterEncoder registers a BodyEncoder for the given content type, which is used when the Accept header of the
+// request prefers it. It overrides the JSON encoder if the content type is "application/json". The built-in
+// encoders EncodeXML, EncodeYAML, EncodeMsgpack and EncodeText can be registered with it.
+func (e *Engine) RegisterEncoder(contentType string, enc BodyEncoder) {
+	e.encoders.register(strings.ToLower(contentType), enc)
+}
+
+// SetCompactJSON sets whether the JSON responses are written compact instead of indented, which is the default
+func (e *Engine) SetCompactJSON(compact bool) {
+	e.encoders.compactJSON = compact
+}
+
+func (enc *encoders) register(contentType string, fn BodyEncoder) {
+	if _, ok := enc.encoders[contentType]; !ok {
+		enc.contentTypes = append(enc.contentTypes, contentType)
+	}
+	enc.encoders[contentType] = fn
+}
+
+// acceptedType is a media range in the Accept header with its quality
+type acceptedType struct {
+	mediaRange string
+	quality    float64
+}
+
+// parseAccept parses the Accept header into media ranges, sorted by their quality
+func parseAccept(accept string) []acceptedType {
+	var accepted []acceptedType
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		if quality > 0 {
+			accepted = append(accepted, acceptedType{mediaRange, quality})
+		}
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+	return accepted
+}
+
+// negotiate returns the content types of the encoders accepted by the Accept header, the most preferred one first.
+// The first registered encoder is used if the header is missing.
+func (enc *encoders) negotiate(accept string) []string {
+	if accept == "" {
+		return enc.contentTypes[:1]
+	}
+
+	var contentTypes []string
+	seen := make(map[string]bool)
+	add := func(contentType string) {
+		if !seen[contentType] {
+			seen[contentType] = true
+			contentTypes = append(contentTypes, contentType)
+		}
+	}
+
+	for _, accepted := range parseAccept(accept) {
+		if _, ok := enc.encoders[accepted.mediaRange]; ok {
+			add(accepted.mediaRange)
+			continue
+		}
+
+		// Wildcards, i.e. */* or text/*
+		prefix := strings.TrimSuffix(accepted.mediaRange, "*")
+		if prefix == "*/" {
+			prefix = ""
+		} else if !strings.HasSuffix(prefix, "/") {
+			continue
+		}
+
+		for _, contentType := range enc.contentTypes {
+			if strings.HasPrefix(contentType, prefix) {
+				add(contentType)
+			}
+		}
+	}
+	return contentTypes
+}
+
+// encode encodes the value with the first of the content types whose encoder succeeds, so that a value one of them
+// cannot encode, i.e. a map for XML, is encoded with the next one the client accepts
+func (enc *encoders) encode(v interface{}, contentTypes []string, logger *log.Logger) (string, []byte, error) {
+	var err error
+	for _, contentType := range contentTypes {
+		var b []byte
+		b, err = callEncoder(enc.encoders[contentType], v)
+		if err == nil {
+			return contentType, b, nil
+		}
+
+		logger.Printf("Could not serialize the response as %s: %v\n", contentType, err)
+	}
+	return "", nil, err
+}
+
+// callEncoder calls the encoder, turning its panic into an error since some of them panic on the types they do not
+// support, i.e. YAML on channels
+func callEncoder(fn BodyEncoder, v interface{}) (b []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("the encoder panicked: %v", r)
+		}
+	}()
+	return fn(v)
+}
+
+func (enc *encoders) encodeJSON(v interface{}) ([]byte, error) {
+	if enc.compactJSON {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// EncodeXML is the BodyEncoder for XML, which can be registered for "application/xml" and "text/xml"
+func EncodeXML(v interface{}) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+// EncodeYAML is the BodyEncoder for YAML, which can be registered for "application/yaml", "application/x-yaml" and
+// "text/yaml"
+func EncodeYAML(v interface{}) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+// EncodeMsgpack is the BodyEncoder for MessagePack, which can be registered for "application/msgpack",
+// "application/x-msgpack" and "application/vnd.msgpack"
+func EncodeMsgpack(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// EncodeText is the BodyEncoder for "text/plain", which writes strings and errors as is and formats the other
+// values with fmt
+func EncodeText(v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		return []byte(v), nil
+	case error:
+		return []byte(v.Error()), nil
+	case fmt.Stringer:
+		return []byte(v.String()), nil
+	}
+	return []byte(fmt.Sprint(v)), nil
+}
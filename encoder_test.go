@@ -0,0 +1,168 @@
+package gongular
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type encoderHandler struct{}
+
+type encodedPerson struct {
+	Name string `json:"name" xml:"name" yaml:"name" msgpack:"name"`
+	Age  int    `json:"age" xml:"age" yaml:"age" msgpack:"age"`
+}
+
+func (e *encoderHandler) Handle(c *Context) error {
+	c.SetBody(encodedPerson{Name: "zeynep", Age: 3})
+	return nil
+}
+
+func getWithAccept(t *testing.T, e *Engine, accept string) (*httptest.ResponseRecorder, string) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	return serve(t, e, req)
+}
+
+func registerBuiltInEncoders(e *Engine) {
+	e.RegisterEncoder("application/xml", EncodeXML)
+	e.RegisterEncoder("application/yaml", EncodeYAML)
+	e.RegisterEncoder("application/msgpack", EncodeMsgpack)
+	e.RegisterEncoder("text/plain", EncodeText)
+}
+
+func TestEncoder_JSONOnlyByDefault(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &encoderHandler{})
+
+	resp, content := getWithAccept(t, e, browserAccept)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/json", resp.Header().Get("Content-type"))
+	assert.JSONEq(t, `{"name": "zeynep", "age": 3}`, content)
+
+	resp, _ = getWithAccept(t, e, "application/xml")
+	assert.Equal(t, http.StatusNotAcceptable, resp.Code)
+}
+
+func TestEncoder_Negotiation(t *testing.T) {
+	e := newEngineTest()
+	registerBuiltInEncoders(e)
+	e.GetRouter().GET("/", &encoderHandler{})
+
+	cases := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "application/json", "{\n  \"name\": \"zeynep\",\n  \"age\": 3\n}"},
+		{"*/*", "application/json", "{\n  \"name\": \"zeynep\",\n  \"age\": 3\n}"},
+		{"application/xml", "application/xml", "<encodedPerson><name>zeynep</name><age>3</age></encodedPerson>"},
+		{"text/html, application/yaml;q=0.9, application/json;q=0.5", "application/yaml", "name: zeynep\nage: 3\n"},
+		{"text/plain", "text/plain; charset=utf-8", "{zeynep 3}"},
+	}
+
+	for _, tc := range cases {
+		resp, content := getWithAccept(t, e, tc.accept)
+		assert.Equal(t, http.StatusOK, resp.Code, tc.accept)
+		assert.Equal(t, tc.contentType, resp.Header().Get("Content-type"), tc.accept)
+		assert.Equal(t, tc.body, content, tc.accept)
+	}
+}
+
+func TestEncoder_Msgpack(t *testing.T) {
+	e := newEngineTest()
+	registerBuiltInEncoders(e)
+	e.GetRouter().GET("/", &encoderHandler{})
+
+	resp, content := getWithAccept(t, e, "application/msgpack")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var p encodedPerson
+	require.NoError(t, msgpack.Unmarshal([]byte(content), &p))
+	assert.Equal(t, encodedPerson{Name: "zeynep", Age: 3}, p)
+}
+
+func TestEncoder_NotAcceptable(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &encoderHandler{})
+
+	resp, content := getWithAccept(t, e, "image/png, application/json;q=0")
+	assert.Equal(t, http.StatusNotAcceptable, resp.Code)
+	assert.Equal(t, "Not Acceptable", content)
+}
+
+func TestEncoder_CompactAndCustom(t *testing.T) {
+	e := newEngineTest()
+	e.SetCompactJSON(true)
+	e.RegisterEncoder("text/csv", func(v interface{}) ([]byte, error) {
+		p := v.(encodedPerson)
+		return []byte(p.Name + ",3\n"), nil
+	})
+	e.GetRouter().GET("/", &encoderHandler{})
+
+	_, content := getWithAccept(t, e, "")
+	assert.Equal(t, `{"name":"zeynep","age":3}`, content)
+
+	resp2, content2 := getWithAccept(t, e, "text/csv")
+	assert.Equal(t, "text/csv; charset=utf-8", resp2.Header().Get("Content-type"))
+	assert.Equal(t, "zeynep,3\n", content2)
+}
+
+const browserAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+
+type encoderMapHandler struct {
+	Query struct {
+		Age int `valid:"range(0|150)"`
+	}
+}
+
+func (e *encoderMapHandler) Handle(c *Context) error {
+	c.SetBody(map[string]int{"a": 1})
+	return nil
+}
+
+type encoderChanHandler struct{}
+
+func (e *encoderChanHandler) Handle(c *Context) error {
+	c.SetBody(make(chan int))
+	return nil
+}
+
+func TestEncoder_Fallback(t *testing.T) {
+	e := newEngineTest()
+	registerBuiltInEncoders(e)
+	e.GetRouter().GET("/", &encoderMapHandler{})
+	e.GetRouter().GET("/chan", &encoderChanHandler{})
+
+	// XML is preferred by the browsers, but it cannot encode maps
+	resp, content := getWithAccept(t, e, browserAccept)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/json", resp.Header().Get("Content-type"))
+	assert.JSONEq(t, `{"a": 1}`, content)
+
+	// The bodies of the default error handler are maps as well
+	req := httptest.NewRequest(http.MethodGet, "/?Age=200", nil)
+	req.Header.Set("Accept", browserAccept)
+	resp, content = serve(t, e, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, "application/json", resp.Header().Get("Content-type"))
+	assert.Contains(t, content, "ValidationError")
+
+	// Only XML is accepted, which cannot encode the map
+	resp, content = getWithAccept(t, e, "application/xml")
+	assert.Equal(t, http.StatusNotAcceptable, resp.Code)
+	assert.Equal(t, "Not Acceptable", content)
+
+	// None of the accepted encoders can encode a channel, not even JSON
+	req = httptest.NewRequest(http.MethodGet, "/chan", nil)
+	req.Header.Set("Accept", "application/yaml, application/json;q=0.5")
+	resp, content = serve(t, e, req)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, "Internal Server Error", content)
+}
@@ -1,8 +1,12 @@
 package gongular
 
 import (
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"reflect"
+	"sync"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -15,25 +19,58 @@ type Engine struct {
 	// Injector
 	injector *injector
 
+	// The converters and decoders for binding
+	binder *binder
+
+	// The encoders for the response bodies
+	encoders *encoders
+
+	// The maximum size of the request bodies, zero if unlimited
+	maxBodySize int64
+
 	// HTTP Router
 	httpRouter *Router
 	// WS Router
 	wsRouter *WSRouter
 
+	// The registered routes
+	routes []*route
+
+	// Whether the registration errors stop the program, otherwise they are collected for Validate
+	fatalRegistration  bool
+	registrationErrors []RouteError
+
 	// The callback for route callbacks
 	callback RouteCallback
 
 	// The error handler
 	errorHandler ErrorHandler
+
+	// The panic handler
+	panicHandler PanicHandler
+
+	// The underlying HTTP server and its lifecycle hooks, the server can be shut down from another goroutine while
+	// it is starting
+	serverMu      sync.Mutex
+	server        *http.Server
+	serverOptions ServerOptions
+	shutdown      bool
+	onStart       []func(addr net.Addr)
+	onShutdown    []func()
 }
 
 // NewEngine creates a new engine with the proper fields initialized
 func NewEngine() *Engine {
 	e := &Engine{
 		errorHandler: defaultErrorHandler,
+		panicHandler: defaultPanicHandler,
 		actualRouter: httprouter.New(),
 		injector:     newInjector(),
+		binder:       newBinder(),
+		encoders:     newEncoders(),
 		callback:     DefaultRouteCallback,
+
+		fatalRegistration: true,
 	}
 
 	e.httpRouter = newRouter(e)
@@ -51,6 +88,95 @@ func (e *Engine) GetWSRouter() *WSRouter {
 	return e.wsRouter
 }
 
+// Routes returns the information of the registered HTTP routes in the order they are registered
+func (e *Engine) Routes() []RouteInfo {
+	infos := make([]RouteInfo, len(e.routes))
+	for i, rt := range e.routes {
+		tips := make([]reflect.Type, len(rt.handlers))
+		for j, hc := range rt.handlers {
+			tips[j] = hc.tip
+		}
+
+		infos[i] = RouteInfo{
+			Method:    rt.method,
+			Path:      rt.path,
+			Handlers:  tips,
+			Responses: rt.describeResponses(),
+		}
+	}
+	return infos
+}
+
+// SetFatalRegistration sets whether a route that cannot be registered stops the program with log.Fatal, which is the
+// default. Otherwise the route is skipped and the error is returned from Validate.
+func (e *Engine) SetFatalRegistration(fatal bool) {
+	e.fatalRegistration = fatal
+}
+
+// Validate checks the dependencies of the provided constructors, then returns a RegistrationError listing every
+// route that could not be registered, or nil if all of them are registered
+func (e *Engine) Validate() error {
+	err := e.injector.checkConstructors()
+	if err != nil {
+		return err
+	}
+
+	if len(e.registrationErrors) == 0 {
+		return nil
+	}
+
+	routes := make([]RouteError, len(e.registrationErrors))
+	copy(routes, e.registrationErrors)
+	return RegistrationError{Routes: routes}
+}
+
+// registrationError stops the program or collects the error depending on the registration mode
+func (e *Engine) registrationError(method, path string, err error) {
+	routeErr := RouteError{
+		Method: method,
+		Path:   path,
+		Err:    err,
+	}
+
+	if e.fatalRegistration {
+		log.Fatal(routeErr)
+	}
+	e.registrationErrors = append(e.registrationErrors, routeErr)
+}
+
+// NewContext creates a context for the request as if it is matched to the path with the parameters, so that a
+// handler can be executed with it by Execute
+func (e *Engine) NewContext(path string, w http.ResponseWriter, r *http.Request, params httprouter.Params) *Context {
+	logger := log.New(log.Writer(), "", log.LstdFlags)
+	return contextFromRequest(path, w, r, params, logger, e.encoders)
+}
+
+// Execute analyzes the handler as if it is registered to the path of the context, then calls it with the request
+// without routing. The finalizers of the provided values are run before it returns, and the error of the first failing
+// one is returned if the handler succeeds. The response is written when the context is finalized. If the handler
+// panics, it is panicked again with an error that has the stack trace of the handler.
+func (e *Engine) Execute(c *Context, handler RequestHandler) error {
+	hc, err := transformRequestHandler(c.path, c.r.Method, e.injector, e.binder, handler)
+	if err != nil {
+		return err
+	}
+
+	rp, err := hc.RequestHandler.safeExecute(c)
+	ferrs := c.runFinalizers(chainError(rp, err))
+	for _, ferr := range ferrs {
+		c.logger.Println("A finalizer has failed:", ferr)
+	}
+	c.cleanup()
+
+	if rp != nil {
+		// The stack is where the handler panicked, not here
+		panic(fmt.Errorf("%v\n%s", rp.value, rp.stack))
+	} else if err == nil && len(ferrs) > 0 {
+		return ferrs[0]
+	}
+	return err
+}
+
 // ServeFiles serves the static files
 func (e *Engine) ServeFiles(path string, root http.FileSystem) {
 	e.actualRouter.ServeFiles(path+"/*filepath", root)
@@ -74,14 +200,15 @@ func (e *Engine) GetHandler() http.Handler {
 	return e.actualRouter
 }
 
-// ListenAndServe serves the given engine with a specific address. Mainly used for quick testing.
+// ListenAndServe serves the given engine with a specific address. Mainly used for quick testing, use Server and
+// Run for configuring the server and graceful shutdown.
 func (e *Engine) ListenAndServe(addr string) error {
-	return http.ListenAndServe(addr, e.actualRouter)
+	return e.Server(ServerOptions{Addr: addr}).ListenAndServe()
 }
 
 // ListenAndServeTLS serves the given engine with a specific address on HTTPs.
 func (e *Engine) ListenAndServeTLS(addr, certFile, keyFile string) error {
-	return http.ListenAndServeTLS(addr, certFile, keyFile, e.actualRouter)
+	return e.Server(ServerOptions{Addr: addr}).ListenAndServeTLS(certFile, keyFile)
 }
 
 // Provide provides with "default" key
@@ -89,6 +216,20 @@ func (e *Engine) Provide(value interface{}) {
 	e.injector.Provide(value, "default")
 }
 
+// Provide provides the value as the type T with a key, so that T can be an interface the handlers depend on
+func Provide[T any](e *Engine, value T, key string) {
+	e.injector.provideAs(reflect.TypeOf((*T)(nil)).Elem(), value, key)
+}
+
+// OverrideProviders provides the values given by fn in precedence over the other providers of the engine and its
+// groups, i.e. to replace the dependencies with mocks in a test. It must be called before the handlers are registered.
+func (e *Engine) OverrideProviders(fn func(p *Providers)) {
+	if e.injector.overrides == nil {
+		e.injector.overrides = e.injector.child()
+	}
+	fn(&Providers{injector: e.injector.overrides})
+}
+
 // ProvideUnsafe provides a key with an exact value
 func (e *Engine) ProvideUnsafe(key string, value interface{}) {
 	e.injector.ProvideUnsafe(key, value)
@@ -109,6 +250,41 @@ func (e *Engine) CustomProvideWithKey(key string, value interface{}, fn CustomPr
 	e.injector.ProvideCustom(value, fn, key)
 }
 
+// ProvideScoped provides with "default" key by calling the supplied CustomProvideFunction once per scope
+func (e *Engine) ProvideScoped(value interface{}, scope Scope, fn CustomProvideFunction) {
+	e.injector.ProvideScoped(value, fn, "default", scope)
+}
+
+// ProvideFinalized provides with "default" key by calling the supplied FinalizedProvideFunction once per request,
+// and the Finalizer it returns after the last handler of the request
+func (e *Engine) ProvideFinalized(value interface{}, fn FinalizedProvideFunction) {
+	e.injector.ProvideFinalized(value, fn, "default")
+}
+
+// ProvideFinalizedWithKey provides with a key by calling the supplied FinalizedProvideFunction once per request,
+// and the Finalizer it returns after the last handler of the request
+func (e *Engine) ProvideFinalizedWithKey(key string, value interface{}, fn FinalizedProvideFunction) {
+	e.injector.ProvideFinalized(value, fn, key)
+}
+
+// ProvideConstructor provides the value returned by the function with "default" key, whose parameters are provided
+// values as well. The function can return an error as its second result, and it is called once when the value is
+// first needed.
+func (e *Engine) ProvideConstructor(fn interface{}) {
+	e.injector.ProvideConstructor(fn, "default")
+}
+
+// ProvideConstructorWithKey provides the value returned by the function with a key, whose parameters are provided
+// values as well
+func (e *Engine) ProvideConstructorWithKey(key string, fn interface{}) {
+	e.injector.ProvideConstructor(fn, key)
+}
+
+// ProvideScopedWithKey provides with a key by calling the supplied CustomProvideFunction once per scope
+func (e *Engine) ProvideScopedWithKey(key string, value interface{}, scope Scope, fn CustomProvideFunction) {
+	e.injector.ProvideScoped(value, fn, key, scope)
+}
+
 // SetErrorHandler sets the error handler
 func (e *Engine) SetErrorHandler(fn ErrorHandler) {
 	if fn == nil {
@@ -117,6 +293,14 @@ func (e *Engine) SetErrorHandler(fn ErrorHandler) {
 	e.errorHandler = fn
 }
 
+// SetPanicHandler sets the panic handler which is called when a handler panics instead of dropping the connection
+func (e *Engine) SetPanicHandler(fn PanicHandler) {
+	if fn == nil {
+		log.Fatal("The panic handler cannot be nil")
+	}
+	e.panicHandler = fn
+}
+
 // SetRouteCallback sets the callback function that is called when the route ends, which contains stats about the
 // executed functions in that request
 func (e *Engine) SetRouteCallback(fn RouteCallback) {
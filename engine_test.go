@@ -1,14 +1,19 @@
 package gongular
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"bytes"
 	"io/ioutil"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type errorTester struct{}
@@ -109,3 +114,145 @@ func TestEngineFileServe(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, resp3.Code)
 
 }
+
+type panicTester struct{}
+
+func (p *panicTester) Handle(c *Context) error {
+	panic("oops")
+}
+
+func TestEngine_DefaultPanicHandler(t *testing.T) {
+	var stat RouteStat
+	e := newEngineTest()
+	e.SetRouteCallback(func(s RouteStat) {
+		stat = s
+	})
+	e.GetRouter().GET("/", &panicTester{}, &simpleHandler{})
+
+	resp, content := get(t, e, "/")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, `"Internal Server Error"`, content)
+
+	assert.Equal(t, http.StatusInternalServerError, stat.ResponseCode)
+	assert.Equal(t, "oops", stat.Handlers[0].Panic)
+	assert.NotEmpty(t, stat.Handlers[0].Stack)
+	assert.True(t, stat.Handlers[0].StopChain)
+	assert.Empty(t, stat.Handlers[1].FuncName)
+}
+
+type panicNilTester struct{}
+
+func (p *panicNilTester) Handle(c *Context) error {
+	panic(nil)
+}
+
+type abortTester struct{}
+
+func (a *abortTester) Handle(c *Context) error {
+	panic(http.ErrAbortHandler)
+}
+
+func TestEngine_PanicNil(t *testing.T) {
+	var stat RouteStat
+	e := newEngineTest()
+	e.SetRouteCallback(func(s RouteStat) {
+		stat = s
+	})
+	e.GetRouter().GET("/", &panicNilTester{}, &simpleHandler{})
+
+	resp, _ := get(t, e, "/")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.NotEmpty(t, stat.Handlers[0].Stack)
+	assert.True(t, stat.Handlers[0].StopChain)
+	assert.Empty(t, stat.Handlers[1].FuncName)
+}
+
+func TestEngine_PanicAbortHandler(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &abortTester{})
+
+	// The server aborts the response itself
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		get(t, e, "/")
+	})
+}
+
+func TestEngine_ExecutePanic(t *testing.T) {
+	e := newEngineTest()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := e.NewContext("/", httptest.NewRecorder(), req, nil)
+
+	defer func() {
+		err, ok := recover().(error)
+		require.True(t, ok)
+
+		// The stack is of the handler that has panicked
+		assert.Contains(t, err.Error(), "oops")
+		assert.Contains(t, err.Error(), "(*panicTester).Handle")
+	}()
+	e.Execute(c, &panicTester{})
+}
+
+func TestEngine_SetPanicHandler(t *testing.T) {
+	var recovered interface{}
+	e := newEngineTest()
+	e.SetPanicHandler(func(v interface{}, stack []byte, c *Context) {
+		recovered = v
+		c.MustStatus(http.StatusTeapot)
+		c.SetBody("recovered")
+	})
+	e.GetRouter().GET("/", &panicTester{})
+
+	resp, content := get(t, e, "/")
+	assert.Equal(t, http.StatusTeapot, resp.Code)
+	assert.Equal(t, `"recovered"`, content)
+	assert.Equal(t, "oops", recovered)
+}
+
+func TestEngine_Validate(t *testing.T) {
+	e := newEngineTest()
+	e.SetFatalRegistration(false)
+
+	e.GetRouter().GET("/user/:ID/post/:PostID", &pathCheckHandler{})
+	e.GetRouter().GET("/nested", &nestedQueryHandler{})
+	e.GetRouter().GET("/", &simpleHandler{})
+	e.GetWSRouter().Handle("/ws", &wsMissingProvider{})
+
+	err := e.Validate()
+	require.Error(t, err)
+
+	regErr, ok := err.(RegistrationError)
+	require.True(t, ok)
+	require.Len(t, regErr.Routes, 3)
+	assert.Equal(t, "/user/:ID/post/:PostID", regErr.Routes[0].Path)
+	assert.Equal(t, "/nested", regErr.Routes[1].Path)
+	assert.Equal(t, "/ws", regErr.Routes[2].Path)
+	assert.Contains(t, err.Error(), "3 routes")
+
+	// The misconfigured routes are skipped
+	assert.Len(t, e.Routes(), 1)
+	resp, _ := get(t, e, "/nested")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	assert.Equal(t, err, e.Run(context.Background()))
+}
+
+func TestEngine_ValidateNoErrors(t *testing.T) {
+	e := newEngineTest()
+	e.SetFatalRegistration(false)
+	e.GetRouter().GET("/", &simpleHandler{})
+
+	assert.NoError(t, e.Validate())
+}
+
+type wsMissingProvider struct {
+	DB *sql.DB
+}
+
+func (w *wsMissingProvider) Before(c *Context) (http.Header, error) {
+	return nil, nil
+}
+
+func (w *wsMissingProvider) Handle(conn *websocket.Conn) {
+	conn.Close()
+}
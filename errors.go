@@ -1,6 +1,7 @@
 package gongular
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -21,6 +22,15 @@ var defaultErrorHandler = func(err error, c *Context) {
 	case ParseError:
 		c.MustStatus(http.StatusBadRequest)
 		c.SetBody(map[string]interface{}{"ParseError": err})
+	case BodyTooLargeError:
+		c.MustStatus(http.StatusRequestEntityTooLarge)
+		c.SetBody(map[string]interface{}{"BodyTooLargeError": err})
+	case UnsupportedMediaTypeError:
+		c.MustStatus(http.StatusUnsupportedMediaType)
+		c.SetBody(map[string]interface{}{"UnsupportedMediaTypeError": err})
+	case DeadlineError:
+		c.MustStatus(err.status())
+		c.SetBody(http.StatusText(err.status()))
 	default:
 		c.SetBody(err.Error())
 		c.MustStatus(http.StatusInternalServerError)
@@ -32,9 +42,24 @@ var defaultErrorHandler = func(err error, c *Context) {
 // ErrorHandler is generic interface for error handling
 type ErrorHandler func(err error, c *Context)
 
+var defaultPanicHandler = func(v interface{}, stack []byte, c *Context) {
+	c.logger.Printf("A panic has occurred: %v\n%s", v, stack)
+
+	c.MustStatus(http.StatusInternalServerError)
+	c.SetBody(http.StatusText(http.StatusInternalServerError))
+	c.StopChain()
+}
+
+// PanicHandler is called with the recovered value and the stack trace whenever a handler panics, so that
+// a proper response can still be produced via the Context
+type PanicHandler func(v interface{}, stack []byte, c *Context)
+
 // ErrNoSuchDependency is thrown whenever the requested interface could not be found in the injector
 var ErrNoSuchDependency = errors.New("No such dependency exists")
 
+// ErrAmbiguousDependency is thrown whenever more than one provided type implements the requested interface
+var ErrAmbiguousDependency = errors.New("More than one dependency implements the interface")
+
 // InjectionError occurs whenever the listed dependency cannot be injected
 type InjectionError struct {
 	Tip             reflect.Type
@@ -70,3 +95,79 @@ type ParseError struct {
 func (p ParseError) Error() string {
 	return fmt.Sprintf("Parse error: %s %s %s", p.Place, p.FieldName, p.Reason)
 }
+
+// BodyTooLargeError occurs whenever the request body exceeds the maximum allowed size
+type BodyTooLargeError struct {
+	Limit  int64
+	Reason string
+}
+
+func (b BodyTooLargeError) Error() string {
+	return fmt.Sprintf("Body too large: %s, limit is %d bytes", b.Reason, b.Limit)
+}
+
+// DeadlineError occurs whenever the context of a request is done before the handler chain ends, Err is
+// context.DeadlineExceeded if the timeout of the route is exceeded or context.Canceled if the client has gone
+type DeadlineError struct {
+	Err error
+}
+
+func (d DeadlineError) Error() string {
+	return fmt.Sprintf("The request is stopped: %s", d.Err.Error())
+}
+
+// Unwrap returns the error of the context
+func (d DeadlineError) Unwrap() error {
+	return d.Err
+}
+
+// status returns 504 if the deadline is exceeded, and 503 if the request is canceled
+func (d DeadlineError) status() int {
+	if errors.Is(d.Err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusServiceUnavailable
+}
+
+// UnsupportedMediaTypeError occurs whenever there is no decoder for the content type of the request body
+type UnsupportedMediaTypeError struct {
+	ContentType string
+}
+
+func (u UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("Unsupported media type: %s", u.ContentType)
+}
+
+// DependencyCycleError occurs whenever the provided constructors depend on each other in a cycle, the cycle starts
+// and ends with the same type
+type DependencyCycleError struct {
+	Cycle []string
+}
+
+func (d DependencyCycleError) Error() string {
+	return fmt.Sprintf("Dependency cycle: %s", strings.Join(d.Cycle, " -> "))
+}
+
+// RouteError occurs whenever a handler of a route cannot be registered, i.e. a Param field is missing in the path
+type RouteError struct {
+	Method string
+	Path   string
+	Err    error
+}
+
+func (r RouteError) Error() string {
+	return fmt.Sprintf("Route %s %s: %s", r.Method, r.Path, r.Err.Error())
+}
+
+// RegistrationError lists all the routes that could not be registered
+type RegistrationError struct {
+	Routes []RouteError
+}
+
+func (r RegistrationError) Error() string {
+	s := make([]string, len(r.Routes))
+	for i, err := range r.Routes {
+		s[i] = err.Error()
+	}
+	return fmt.Sprintf("%d routes could not be registered:\n%s", len(r.Routes), strings.Join(s, "\n"))
+}
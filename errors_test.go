@@ -332,3 +332,44 @@ func TestInvalidErrors_NoFileSupplied(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.Code)
 	assert.NotEqual(t, `"WOW"`, content)
 }
+
+func TestInvalidErrors_Header(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &headerCookieHandler{})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Retries", "many")
+	resp, content := serve(t, e, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, content, PlaceHeader)
+
+	req2 := httptest.NewRequest(http.MethodGet, "/", nil)
+	req2.Header.Set("X-Request-ID", "not-alpha-numeric")
+	resp2, content2 := serve(t, e, req2)
+	assert.Equal(t, http.StatusBadRequest, resp2.Code)
+	assert.Contains(t, content2, "ValidationError")
+}
+
+func TestInvalidErrors_Cookie(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &headerCookieHandler{})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "Dark", Value: "maybe"})
+	resp, content := serve(t, e, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, content, PlaceCookie)
+}
+
+func TestInvalidErrors_MultiValueIndex(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &multiValueQueryHandler{})
+
+	resp, content := get(t, e, "/?id=1,x")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, content, `"FieldName": "IDs[1]"`)
+
+	resp2, content2 := get(t, e, "/?Point=1&Point=2&Point=3")
+	assert.Equal(t, http.StatusBadRequest, resp2.Code)
+	assert.Contains(t, content2, "Expected at most 2 values but got 3")
+}
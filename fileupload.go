@@ -1,11 +1,199 @@
 package gongular
 
 import (
+	"fmt"
+	"io"
+	"mime"
 	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
 // UploadedFile packs the file and the header of the file to one struct
 type UploadedFile struct {
-	File   multipart.File
-	Header *multipart.FileHeader
+	File   multipart.File        `valid:"-"`
+	Header *multipart.FileHeader `valid:"-"`
+}
+
+var (
+	uploadedFilesType   = reflect.TypeOf([]*UploadedFile{})
+	multipartReaderType = reflect.TypeOf(&multipart.Reader{})
+)
+
+// SetMultipartMemory sets the maximum bytes of the multipart forms kept in memory, the rest of the files are stored
+// in temporary files which are removed when the request is finished. It is 32 MB by default.
+func (e *Engine) SetMultipartMemory(n int64) {
+	e.binder.maxMemory = n
+}
+
+// isFileField returns whether the form field is bound from the uploaded files instead of the values
+func isFileField(tip reflect.Type) bool {
+	return tip == uploadedFileType || tip == uploadedFilesType
+}
+
+// hasMultipartReader returns whether the form streams the multipart body instead of parsing it
+func hasMultipartReader(tip reflect.Type) bool {
+	for i := 0; i < tip.NumField(); i++ {
+		if tip.Field(i).Type == multipartReaderType {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFileTags checks whether the tags of the file fields in the form are well formed
+func checkFileTags(tip reflect.Type) error {
+	if tip.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < tip.NumField(); i++ {
+		field := tip.Field(i)
+		if tag, ok := field.Tag.Lookup(TagMaxSize); ok {
+			if _, err := strconv.ParseInt(tag, 10, 64); err != nil {
+				return fmt.Errorf("Form field %s has the %s tag '%s' which is not an integer", field.Name, TagMaxSize, tag)
+			}
+		}
+	}
+	return nil
+}
+
+// parseFiles sets a file field of the form from the parsed multipart form, it returns false if there is no file and
+// the reason if a file does not satisfy the tags of the field
+func (c *Context) parseFiles(field reflect.StructField, val reflect.Value) (bool, string, error) {
+	var headers []*multipart.FileHeader
+	if mf := c.Request().MultipartForm; mf != nil {
+		headers = mf.File[field.Name]
+	}
+
+	if len(headers) == 0 {
+		return false, "", nil
+	} else if field.Type == uploadedFileType {
+		// Only the first one is used like FormFile does
+		headers = headers[:1]
+	}
+
+	files := make([]*UploadedFile, len(headers))
+	for i, header := range headers {
+		file, err := header.Open()
+		if err != nil {
+			// It should be an internal error, therefore we do not wrap with ParseError
+			return false, "", err
+		}
+		c.files = append(c.files, file)
+
+		invalid, err := checkUploadedFile(file, header, field)
+		if err != nil || invalid != "" {
+			return true, invalid, err
+		}
+
+		files[i] = &UploadedFile{
+			File:   file,
+			Header: header,
+		}
+	}
+
+	if field.Type == uploadedFileType {
+		val.Set(reflect.ValueOf(files[0]))
+	} else {
+		val.Set(reflect.ValueOf(files))
+	}
+	return true, "", nil
+}
+
+// checkUploadedFile checks the size, the extension and the content type of an uploaded file against the tags of the
+// field, and returns the reason if it does not satisfy them
+func checkUploadedFile(file multipart.File, header *multipart.FileHeader, field reflect.StructField) (string, error) {
+	if tag, ok := field.Tag.Lookup(TagMaxSize); ok {
+		// The tag is checked when the handler is registered
+		maxSize, _ := strconv.ParseInt(tag, 10, 64)
+		if header.Size > maxSize {
+			return fmt.Sprintf("The file '%s' is larger than %d bytes", header.Filename, maxSize), nil
+		}
+	}
+
+	if tag, ok := field.Tag.Lookup(TagExtension); ok {
+		ext := strings.ToLower(filepath.Ext(header.Filename))
+		if !matchExtension(ext, tag) {
+			return fmt.Sprintf("The file '%s' does not have one of the extensions '%s'", header.Filename, tag), nil
+		}
+	}
+
+	if tag, ok := field.Tag.Lookup(TagMimeType); ok {
+		contentType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+		if !matchMimeType(contentType, tag) {
+			return fmt.Sprintf("The file '%s' has the type '%s' which is not one of '%s'", header.Filename, contentType, tag), nil
+		}
+	}
+
+	if tag, ok := field.Tag.Lookup(TagSniff); ok {
+		contentType, err := sniffContentType(file)
+		if err != nil {
+			return "", err
+		}
+
+		if !matchMimeType(contentType, tag) {
+			return fmt.Sprintf("The content of the file '%s' is '%s' which is not one of '%s'", header.Filename, contentType, tag), nil
+		}
+	}
+	return "", nil
+}
+
+// sniffContentType detects the content type of the file from its first 512 bytes, then rewinds it for the handler
+func sniffContentType(file multipart.File) (string, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	return contentType, nil
+}
+
+// matchExtension returns whether the extension is one of the comma separated extensions, i.e. ".png,.jpg"
+func matchExtension(ext string, allowed string) bool {
+	for _, e := range strings.Split(allowed, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMimeType returns whether the content type matches one of the comma separated types, i.e. "image/*,text/plain"
+func matchMimeType(contentType string, allowed string) bool {
+	for _, t := range strings.Split(allowed, ",") {
+		t = strings.TrimSpace(t)
+		if t == contentType || t == "*/*" {
+			return true
+		} else if strings.HasSuffix(t, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(t, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanup closes the uploaded files and removes the temporary files of the multipart form
+func (c *Context) cleanup() {
+	for _, file := range c.files {
+		file.Close()
+	}
+
+	if mf := c.Request().MultipartForm; mf != nil {
+		mf.RemoveAll()
+	}
 }
@@ -0,0 +1,308 @@
+package gongular
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPart struct {
+	field       string
+	fileName    string
+	contentType string
+	content     string
+}
+
+func postMultipart(t *testing.T, e *Engine, path string, parts ...testPart) (*httptest.ResponseRecorder, string) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+
+	for _, part := range parts {
+		if part.fileName == "" {
+			require.NoError(t, w.WriteField(part.field, part.content))
+			continue
+		}
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, part.field, part.fileName))
+		h.Set("Content-Type", part.contentType)
+		pw, err := w.CreatePart(h)
+		require.NoError(t, err)
+		_, err = io.WriteString(pw, part.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	resp := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodPost, path, &b)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	e.GetHandler().ServeHTTP(resp, req)
+	p, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(p)
+}
+
+// spilledFiles holds the entries of the temporary directory while multiFileHandler is running
+var spilledFiles []string
+
+func listTempDir() []string {
+	entries, _ := ioutil.ReadDir(os.TempDir())
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+type multiFileHandler struct {
+	Form struct {
+		Files []*UploadedFile
+		Title string
+	}
+}
+
+func (m *multiFileHandler) Handle(c *Context) error {
+	spilledFiles = listTempDir()
+
+	contents := []string{}
+	for _, f := range m.Form.Files {
+		p, err := ioutil.ReadAll(f.File)
+		if err != nil {
+			return err
+		}
+		contents = append(contents, f.Header.Filename+"="+string(p))
+	}
+	c.SetBody(m.Form.Title + ":" + strings.Join(contents, ","))
+	return nil
+}
+
+func TestFormWithMultipleFiles(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/upload", &multiFileHandler{})
+
+	resp, content := postMultipart(t, e, "/upload",
+		testPart{field: "Title", content: "docs"},
+		testPart{field: "Files", fileName: "a.txt", contentType: "text/plain", content: "first"},
+		testPart{field: "Files", fileName: "b.txt", contentType: "text/plain", content: "second"},
+	)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"docs:a.txt=first,b.txt=second"`, content)
+}
+
+func TestFormWithMultipleFiles_TemporaryFilesRemoved(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gongular")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	oldTempDir := os.Getenv("TMPDIR")
+	os.Setenv("TMPDIR", tempDir)
+	defer os.Setenv("TMPDIR", oldTempDir)
+
+	e := newEngineTest()
+	e.SetMultipartMemory(1)
+	e.GetRouter().POST("/upload", &multiFileHandler{})
+
+	resp, content := postMultipart(t, e, "/upload",
+		testPart{field: "Files", fileName: "a.txt", contentType: "text/plain", content: strings.Repeat("a", 1024)},
+		testPart{field: "Files", fileName: "b.txt", contentType: "text/plain", content: strings.Repeat("b", 1024)},
+	)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, content, "b.txt=bbb")
+	assert.NotEmpty(t, spilledFiles)
+	assert.Empty(t, listTempDir())
+}
+
+type limitedFileHandler struct {
+	Form struct {
+		Images []*UploadedFile `maxsize:"8" mime:"image/*"`
+	}
+}
+
+func (l *limitedFileHandler) Handle(c *Context) error {
+	c.SetBody(len(l.Form.Images))
+	return nil
+}
+
+func TestFormWithFileLimits(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/upload", &limitedFileHandler{})
+
+	resp, content := postMultipart(t, e, "/upload",
+		testPart{field: "Images", fileName: "a.png", contentType: "image/png", content: "png"},
+		testPart{field: "Images", fileName: "b.gif", contentType: "image/gif", content: "gif"},
+	)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "2", content)
+
+	resp2, content2 := postMultipart(t, e, "/upload",
+		testPart{field: "Images", fileName: "a.png", contentType: "image/png", content: "too large for the limit"},
+	)
+	assert.Equal(t, http.StatusBadRequest, resp2.Code)
+	assert.Contains(t, content2, "ValidationError")
+	assert.Contains(t, content2, "larger than 8 bytes")
+
+	resp3, content3 := postMultipart(t, e, "/upload",
+		testPart{field: "Images", fileName: "a.txt", contentType: "text/plain", content: "text"},
+	)
+	assert.Equal(t, http.StatusBadRequest, resp3.Code)
+	assert.Contains(t, content3, "text/plain")
+}
+
+type streamingFormHandler struct {
+	Form struct {
+		Reader *multipart.Reader
+	}
+}
+
+func (s *streamingFormHandler) Handle(c *Context) error {
+	parts := []string{}
+	for {
+		part, err := s.Form.Reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		n, err := io.Copy(ioutil.Discard, part)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d", part.FormName(), n))
+	}
+	c.SetBody(strings.Join(parts, ","))
+	return nil
+}
+
+func TestFormStreaming(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/upload", &streamingFormHandler{})
+
+	resp, content := postMultipart(t, e, "/upload",
+		testPart{field: "Name", content: "big"},
+		testPart{field: "Data", fileName: "data.bin", contentType: "application/octet-stream", content: strings.Repeat("x", 4096)},
+	)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"Name:3,Data:4096"`, content)
+}
+
+type invalidStreamingFormHandler struct {
+	Form struct {
+		Reader *multipart.Reader
+		Name   string
+	}
+}
+
+func (i *invalidStreamingFormHandler) Handle(c *Context) error {
+	return nil
+}
+
+type invalidMaxSizeHandler struct {
+	Form struct {
+		File *UploadedFile `maxsize:"1MB"`
+	}
+}
+
+func (i *invalidMaxSizeHandler) Handle(c *Context) error {
+	return nil
+}
+
+type nonStructFormHandler struct {
+	Form map[string]string
+}
+
+func (n *nonStructFormHandler) Handle(c *Context) error {
+	return nil
+}
+
+func TestFormFileRegistrationErrors(t *testing.T) {
+	_, err := transformRequestHandler("/", http.MethodPost, newInjector(), newBinder(), &invalidStreamingFormHandler{})
+	assert.Error(t, err)
+
+	_, err = transformRequestHandler("/", http.MethodPost, newInjector(), newBinder(), &invalidMaxSizeHandler{})
+	assert.Error(t, err)
+
+	_, err = transformRequestHandler("/", http.MethodPost, newInjector(), newBinder(), &nonStructFormHandler{})
+	assert.EqualError(t, err, "Form field added but it is not a struct")
+}
+
+type profileEditHandler struct {
+	Form struct {
+		Avatar *UploadedFile `valid:"optional" maxsize:"64" ext:".png,.gif" sniff:"image/png,image/gif"`
+		Name   string
+	}
+}
+
+func (p *profileEditHandler) Handle(c *Context) error {
+	if p.Form.Avatar == nil {
+		c.SetBody(p.Form.Name + ":no avatar")
+		return nil
+	}
+
+	content, err := ioutil.ReadAll(p.Form.Avatar.File)
+	if err != nil {
+		return err
+	}
+	c.SetBody(fmt.Sprintf("%s:%s:%d", p.Form.Name, p.Form.Avatar.Header.Filename, len(content)))
+	return nil
+}
+
+func TestFormWithOptionalFile(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/profile", &profileEditHandler{})
+
+	resp, content := postMultipart(t, e, "/profile",
+		testPart{field: "Name", content: "mustafa"},
+	)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"mustafa:no avatar"`, content)
+
+	gif := "GIF89a" + strings.Repeat("x", 10)
+	resp2, content2 := postMultipart(t, e, "/profile",
+		testPart{field: "Name", content: "mustafa"},
+		testPart{field: "Avatar", fileName: "me.GIF", contentType: "application/octet-stream", content: gif},
+	)
+	assert.Equal(t, http.StatusOK, resp2.Code)
+	assert.Equal(t, `"mustafa:me.GIF:16"`, content2)
+}
+
+func TestFormWithOptionalFile_Invalid(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/profile", &profileEditHandler{})
+
+	resp, content := postMultipart(t, e, "/profile",
+		testPart{field: "Avatar", fileName: "me.png", contentType: "image/png", content: "not really an image"},
+	)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, content, "ValidationError")
+	assert.Contains(t, content, PlaceForm)
+	assert.Contains(t, content, "text/plain")
+
+	resp2, content2 := postMultipart(t, e, "/profile",
+		testPart{field: "Avatar", fileName: "me.jpg", contentType: "image/jpeg", content: "GIF89a"},
+	)
+	assert.Equal(t, http.StatusBadRequest, resp2.Code)
+	assert.Contains(t, content2, "extensions")
+
+	resp3, content3 := postMultipart(t, e, "/profile",
+		testPart{field: "Avatar", fileName: "me.gif", contentType: "image/gif", content: "GIF89a" + strings.Repeat("x", 64)},
+	)
+	assert.Equal(t, http.StatusBadRequest, resp3.Code)
+	assert.Contains(t, content3, "larger than 64 bytes")
+}
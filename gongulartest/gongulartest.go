@@ -0,0 +1,96 @@
+// Package gongulartest helps to test the handlers and the engines of gongular. A Harness builds the requests,
+// serves them with an engine or executes a single handler with them, and asserts on the responses.
+package gongulartest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mustafaakin/gongular"
+)
+
+// Harness serves the requests of a test with its engine
+type Harness struct {
+	t      testing.TB
+	engine *gongular.Engine
+
+	// The stats of the last request served by the engine
+	stat gongular.RouteStat
+}
+
+// New creates a harness with a new engine, whose registration errors fail the test instead of stopping the program
+func New(t testing.TB) *Harness {
+	return NewWithEngine(t, gongular.NewEngine())
+}
+
+// NewWithEngine creates a harness with the engine, its route callback is replaced to keep the stats of the requests
+func NewWithEngine(t testing.TB, e *gongular.Engine) *Harness {
+	h := &Harness{
+		t:      t,
+		engine: e,
+	}
+	e.SetFatalRegistration(false)
+	e.SetRouteCallback(h.callback)
+	return h
+}
+
+func (h *Harness) callback(stat gongular.RouteStat) {
+	h.stat = stat
+}
+
+// Engine returns the engine of the harness
+func (h *Harness) Engine() *gongular.Engine {
+	return h.engine
+}
+
+// Override provides the values in precedence over the ones provided by the application, i.e. the mocks of the test.
+// It must be called before Setup.
+func (h *Harness) Override(fn func(p *gongular.Providers)) *Harness {
+	h.engine.OverrideProviders(fn)
+	return h
+}
+
+// Setup registers the routes of the application with fn, and fails the test if any of them cannot be registered
+func (h *Harness) Setup(fn func(e *gongular.Engine)) *Harness {
+	h.t.Helper()
+
+	fn(h.engine)
+	h.engine.SetRouteCallback(h.callback)
+
+	err := h.engine.Validate()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return h
+}
+
+// NewRequest starts building a request with the method to the path, the path can have parameters like
+// /user/:UserID which are set with Request.Param
+func (h *Harness) NewRequest(method, path string) *Request {
+	return newRequest(h, method, path)
+}
+
+// GET starts building a GET request to the path
+func (h *Harness) GET(path string) *Request {
+	return h.NewRequest(http.MethodGet, path)
+}
+
+// POST starts building a POST request to the path
+func (h *Harness) POST(path string) *Request {
+	return h.NewRequest(http.MethodPost, path)
+}
+
+// PUT starts building a PUT request to the path
+func (h *Harness) PUT(path string) *Request {
+	return h.NewRequest(http.MethodPut, path)
+}
+
+// PATCH starts building a PATCH request to the path
+func (h *Harness) PATCH(path string) *Request {
+	return h.NewRequest(http.MethodPatch, path)
+}
+
+// DELETE starts building a DELETE request to the path
+func (h *Harness) DELETE(path string) *Request {
+	return h.NewRequest(http.MethodDelete, path)
+}
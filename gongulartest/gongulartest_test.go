@@ -0,0 +1,158 @@
+package gongulartest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/mustafaakin/gongular"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type greeter interface {
+	Greet(name string) string
+}
+
+type englishGreeter struct{}
+
+func (englishGreeter) Greet(name string) string {
+	return "Hello " + name
+}
+
+type mockGreeter struct{}
+
+func (mockGreeter) Greet(name string) string {
+	return "mock " + name
+}
+
+type greetHandler struct {
+	Param struct {
+		UserID int
+	}
+	Query struct {
+		Lang string
+	}
+	Header struct {
+		Token string `header:"X-Token"`
+	}
+	Cookie struct {
+		Session string
+	}
+	Body struct {
+		Name string
+	}
+	Greeter greeter
+}
+
+func (g *greetHandler) Handle(c *gongular.Context) error {
+	if g.Header.Token != "secret" {
+		return errors.New("unauthorized")
+	}
+
+	c.Header("X-User", fmt.Sprint(g.Param.UserID))
+	c.SetBody(map[string]string{
+		"Greeting": g.Greeter.Greet(g.Body.Name),
+		"Lang":     g.Query.Lang,
+		"Session":  g.Cookie.Session,
+	})
+	return nil
+}
+
+type uploadHandler struct {
+	Form struct {
+		Title string
+		File  *gongular.UploadedFile
+	}
+}
+
+func (u *uploadHandler) Handle(c *gongular.Context) error {
+	contents, err := ioutil.ReadAll(u.Form.File.File)
+	if err != nil {
+		return err
+	}
+	c.SetBody(fmt.Sprintf("%s:%s:%s", u.Form.Title, u.Form.File.Header.Filename, contents))
+	return nil
+}
+
+func setup(e *gongular.Engine) {
+	gongular.Provide[greeter](e, englishGreeter{}, "default")
+	e.GetRouter().POST("/user/:UserID/greet", &greetHandler{})
+	e.GetRouter().POST("/upload", &uploadHandler{})
+}
+
+func TestHarness_Do(t *testing.T) {
+	h := New(t).Setup(setup)
+
+	resp := h.POST("/user/:UserID/greet").
+		Param("UserID", "42").
+		Query("Lang", "en").
+		Header("X-Token", "secret").
+		Cookie("Session", "abc").
+		JSON(map[string]string{"Name": "mustafa"}).
+		Do()
+
+	resp.AssertStatus(http.StatusOK).
+		AssertHeader("X-User", "42").
+		AssertJSON(map[string]string{"Greeting": "Hello mustafa", "Lang": "en", "Session": "abc"})
+
+	assert.Equal(t, "/user/:UserID/greet", resp.Stat.MatchedPath)
+	require.Len(t, resp.Stat.Handlers, 1)
+	assert.Contains(t, resp.Stat.Handlers[0].FuncName, "greetHandler")
+
+	resp = h.POST("/user/:UserID/greet").Param("UserID", "42").JSON(map[string]string{}).Do()
+	resp.AssertStatus(http.StatusInternalServerError)
+	assert.EqualError(t, resp.Stat.Handlers[0].Error, "unauthorized")
+}
+
+func TestHarness_Override(t *testing.T) {
+	h := New(t).Override(func(p *gongular.Providers) {
+		gongular.ProvideAs[greeter](p, mockGreeter{}, "default")
+	}).Setup(setup)
+
+	var body map[string]string
+	h.POST("/user/:UserID/greet").
+		Param("UserID", "1").
+		Header("X-Token", "secret").
+		JSON(map[string]string{"Name": "mustafa"}).
+		Do().
+		AssertStatus(http.StatusOK).
+		DecodeJSON(&body)
+	assert.Equal(t, "mock mustafa", body["Greeting"])
+}
+
+func TestHarness_File(t *testing.T) {
+	h := New(t).Setup(setup)
+
+	h.POST("/upload").
+		FormValue("Title", "notes").
+		File("File", "notes.txt", []byte("hello")).
+		Do().
+		AssertStatus(http.StatusOK).
+		AssertBody(`"notes:notes.txt:hello"`)
+}
+
+func TestHarness_Invoke(t *testing.T) {
+	h := New(t).Override(func(p *gongular.Providers) {
+		p.Provide(mockGreeter{})
+	})
+
+	h.POST("/user/:UserID/greet").
+		Param("UserID", "7").
+		Header("X-Token", "secret").
+		JSON(map[string]string{"Name": "mustafa"}).
+		Invoke(&greetHandler{}).
+		AssertNoError().
+		AssertStatus(http.StatusOK).
+		AssertHeader("X-User", "7").
+		AssertJSON(map[string]string{"Greeting": "mock mustafa", "Lang": "", "Session": ""})
+
+	resp := h.POST("/user/:UserID/greet").
+		Param("UserID", "7").
+		JSON(map[string]string{}).
+		Invoke(&greetHandler{}).
+		AssertError()
+	assert.EqualError(t, resp.Err, "unauthorized")
+}
@@ -0,0 +1,212 @@
+package gongulartest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mustafaakin/gongular"
+)
+
+// Request builds a request of a test, its methods can be chained
+type Request struct {
+	harness *Harness
+	method  string
+	path    string
+
+	params  httprouter.Params
+	query   url.Values
+	header  http.Header
+	cookies []*http.Cookie
+
+	// The body is either given as is, or built from the form values and the files
+	body        io.Reader
+	contentType string
+	form        url.Values
+	files       []file
+}
+
+// file is a file of a multipart form
+type file struct {
+	field    string
+	name     string
+	contents []byte
+}
+
+func newRequest(h *Harness, method, path string) *Request {
+	return &Request{
+		harness: h,
+		method:  method,
+		path:    path,
+		query:   make(url.Values),
+		header:  make(http.Header),
+		form:    make(url.Values),
+	}
+}
+
+// Param sets the path parameter, which replaces :key in the path
+func (r *Request) Param(key, value string) *Request {
+	r.params = append(r.params, httprouter.Param{Key: key, Value: value})
+	return r
+}
+
+// Query adds the value to the query of the request
+func (r *Request) Query(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// Header adds the value to the header of the request
+func (r *Request) Header(key, value string) *Request {
+	r.header.Add(key, value)
+	return r
+}
+
+// Cookie adds the cookie to the request
+func (r *Request) Cookie(name, value string) *Request {
+	r.cookies = append(r.cookies, &http.Cookie{Name: name, Value: value})
+	return r
+}
+
+// Body sets the body of the request with its content type
+func (r *Request) Body(contentType string, body io.Reader) *Request {
+	r.contentType = contentType
+	r.body = body
+	return r
+}
+
+// JSON sets the body of the request to the JSON encoding of v, it fails the test if v cannot be encoded
+func (r *Request) JSON(v interface{}) *Request {
+	r.harness.t.Helper()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		r.harness.t.Fatal(err)
+	}
+	return r.Body("application/json", bytes.NewReader(b))
+}
+
+// FormValue adds the value to the form of the request, which is sent as a multipart form if it has files
+func (r *Request) FormValue(key, value string) *Request {
+	r.form.Add(key, value)
+	return r
+}
+
+// File adds a file with the name and the contents to the field of the multipart form of the request
+func (r *Request) File(field, name string, contents []byte) *Request {
+	r.files = append(r.files, file{
+		field:    field,
+		name:     name,
+		contents: contents,
+	})
+	return r
+}
+
+// Build creates the http.Request, the parameters are put to the path
+func (r *Request) Build() *http.Request {
+	r.harness.t.Helper()
+
+	body, contentType := r.body, r.contentType
+	if len(r.files) > 0 {
+		body, contentType = r.multipartBody()
+	} else if len(r.form) > 0 {
+		body, contentType = strings.NewReader(r.form.Encode()), "application/x-www-form-urlencoded"
+	}
+
+	target := r.target()
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	req := httptest.NewRequest(r.method, target, body)
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for _, cookie := range r.cookies {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+// target returns the path with its parameters replaced by their values
+func (r *Request) target() string {
+	parts := strings.Split(r.path, "/")
+	for i, part := range parts {
+		if len(part) > 1 && (part[0] == ':' || part[0] == '*') {
+			parts[i] = url.PathEscape(r.params.ByName(part[1:]))
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func (r *Request) multipartBody() (io.Reader, string) {
+	r.harness.t.Helper()
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for key, values := range r.form {
+		for _, value := range values {
+			err := writer.WriteField(key, value)
+			if err != nil {
+				r.harness.t.Fatal(err)
+			}
+		}
+	}
+
+	for _, f := range r.files {
+		part, err := writer.CreateFormFile(f.field, f.name)
+		if err == nil {
+			_, err = part.Write(f.contents)
+		}
+		if err != nil {
+			r.harness.t.Fatal(err)
+		}
+	}
+
+	err := writer.Close()
+	if err != nil {
+		r.harness.t.Fatal(err)
+	}
+	return body, writer.FormDataContentType()
+}
+
+// Do serves the request with the engine of the harness, the response has the stats of the route
+func (r *Request) Do() *Response {
+	r.harness.t.Helper()
+
+	r.harness.stat = gongular.RouteStat{}
+	recorder := httptest.NewRecorder()
+	r.harness.engine.ServeHTTP(recorder, r.Build())
+
+	return &Response{
+		ResponseRecorder: recorder,
+		Stat:             r.harness.stat,
+		t:                r.harness.t,
+	}
+}
+
+// Invoke executes the handler alone with the request, as if it is registered to the path of the request. The
+// error returned by the handler is kept in the response instead of being handled by the engine.
+func (r *Request) Invoke(handler gongular.RequestHandler) *Response {
+	r.harness.t.Helper()
+
+	recorder := httptest.NewRecorder()
+	ctx := r.harness.engine.NewContext(r.path, recorder, r.Build(), r.params)
+	err := r.harness.engine.Execute(ctx, handler)
+	ctx.Finalize()
+
+	return &Response{
+		ResponseRecorder: recorder,
+		Err:              err,
+		t:                r.harness.t,
+	}
+}
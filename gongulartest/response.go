@@ -0,0 +1,79 @@
+package gongulartest
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mustafaakin/gongular"
+	"github.com/stretchr/testify/assert"
+)
+
+// Response is the recorded response of a request, its assertions can be chained
+type Response struct {
+	*httptest.ResponseRecorder
+
+	// The stats of the route if the request is served by the engine
+	Stat gongular.RouteStat
+
+	// The error returned by the handler if the request is invoked with a single handler
+	Err error
+
+	t testing.TB
+}
+
+// AssertStatus asserts the status code of the response
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, code, r.Code, "status code")
+	return r
+}
+
+// AssertBody asserts the body of the response as a string
+func (r *Response) AssertBody(body string) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, body, r.Body.String(), "body")
+	return r
+}
+
+// AssertJSON asserts that the body of the response is equal to the JSON encoding of v
+func (r *Response) AssertJSON(v interface{}) *Response {
+	r.t.Helper()
+
+	expected, err := json.Marshal(v)
+	if assert.NoError(r.t, err) {
+		assert.JSONEq(r.t, string(expected), r.Body.String(), "body")
+	}
+	return r
+}
+
+// AssertHeader asserts the value of a header of the response
+func (r *Response) AssertHeader(key, value string) *Response {
+	r.t.Helper()
+	assert.Equal(r.t, value, r.Header().Get(key), "header %s", key)
+	return r
+}
+
+// AssertError asserts that the invoked handler returned an error
+func (r *Response) AssertError() *Response {
+	r.t.Helper()
+	assert.Error(r.t, r.Err)
+	return r
+}
+
+// AssertNoError asserts that the invoked handler did not return an error
+func (r *Response) AssertNoError() *Response {
+	r.t.Helper()
+	assert.NoError(r.t, r.Err)
+	return r
+}
+
+// DecodeJSON decodes the body of the response to v, it fails the test if it cannot be decoded
+func (r *Response) DecodeJSON(v interface{}) {
+	r.t.Helper()
+
+	err := json.Unmarshal(r.Body.Bytes(), v)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+}
@@ -3,10 +3,12 @@ package gongular
 import (
 	"errors"
 	"reflect"
+	"strings"
 
 	"net/http"
 
 	"fmt"
+	"runtime/debug"
 
 	"github.com/gorilla/websocket"
 )
@@ -18,20 +20,88 @@ type RequestHandler interface {
 
 type middleRequestHandler func(c *Context) error
 
+// recoveredPanic holds the value and the stack trace of a panic that occurred in a handler
+type recoveredPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// chainError returns the error that stopped the chain for the finalizers, which is created from the value of the
+// panic if the handler panicked
+func chainError(rp *recoveredPanic, err error) error {
+	if rp != nil {
+		return fmt.Errorf("panic: %v", rp.value)
+	}
+	return err
+}
+
+// safeExecute calls the handler and recovers from a panic if occurs, so that it can be handled by the engine. The
+// http.ErrAbortHandler panic is left to the server, which aborts the response with it.
+func (fn middleRequestHandler) safeExecute(c *Context) (rp *recoveredPanic, err error) {
+	// recover returns nil for panic(nil) with the Go version of the module, so the handler has panicked if it has
+	// not completed
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+
+		v := recover()
+		if v == http.ErrAbortHandler {
+			panic(v)
+		}
+
+		rp = &recoveredPanic{
+			value: v,
+			stack: debug.Stack(),
+		}
+	}()
+
+	err = fn(c)
+	completed = true
+	return nil, err
+}
+
 type handlerContext struct {
 	method    string
 	name      string
 	websocket bool
 	// The analyzed reflection data so that we can cache it
-	param     bool
-	query     bool
-	body      bool
-	form      bool
-	injection bool
+	param  bool
+	query  bool
+	body   bool
+	form   bool
+	header bool
+	cookie bool
+
+	// The compiled bindings of the special fields
+	paramPlan  *bindingPlan
+	queryPlan  *bindingPlan
+	headerPlan *bindingPlan
+	cookiePlan *bindingPlan
+	formPlan   *bindingPlan
+
+	// The registered handler and the indices of its fields that are copied to each request
+	prototype  reflect.Value
+	configured []int
+
+	// The providers of the injected fields
+	injections []resolvedInjection
+
+	// The declared responses
+	response       bool
+	responseStatus int
+	responses      []ResponseDescription
 
 	// HandlerType
 	tip reflect.Type
 
+	// The engine wide binding configuration
+	binder *binder
+
+	// The open websocket connections of the router, if it is a websocket handler
+	connections *wsConnections
+
 	// The actual function
 	RequestHandler middleRequestHandler
 }
@@ -41,8 +111,6 @@ func (hc *handlerContext) checkParam(handlerElem reflect.Type) error {
 	param, paramOk := handlerElem.FieldByName(FieldParameter)
 	if paramOk {
 		// If we have something param, it should be a struct only
-		// TODO: Additional check, it should be flat struct
-		// TODO: Additional check, it should be compatible with path
 		if param.Type.Kind() != reflect.Struct {
 			return errors.New("Param field added but it is not a struct")
 		}
@@ -55,7 +123,6 @@ func (hc *handlerContext) checkQuery(handlerElem reflect.Type) error {
 	query, queryOk := handlerElem.FieldByName(FieldQuery)
 	if queryOk {
 		// If we have something param, it should be a struct only
-		// TODO: Additional check, it should be flat struct
 		if query.Type.Kind() != reflect.Struct {
 			return errors.New("Query field added but it is not a struct")
 		}
@@ -64,6 +131,28 @@ func (hc *handlerContext) checkQuery(handlerElem reflect.Type) error {
 	return nil
 }
 
+func (hc *handlerContext) checkHeader(handlerElem reflect.Type) error {
+	header, headerOk := handlerElem.FieldByName(FieldHeader)
+	if headerOk {
+		if header.Type.Kind() != reflect.Struct {
+			return errors.New("Header field added but it is not a struct")
+		}
+	}
+	hc.header = headerOk
+	return nil
+}
+
+func (hc *handlerContext) checkCookie(handlerElem reflect.Type) error {
+	cookie, cookieOk := handlerElem.FieldByName(FieldCookie)
+	if cookieOk {
+		if cookie.Type.Kind() != reflect.Struct {
+			return errors.New("Cookie field added but it is not a struct")
+		}
+	}
+	hc.cookie = cookieOk
+	return nil
+}
+
 func (hc *handlerContext) checkBody(handlerElem reflect.Type) error {
 	_, bodyOk := handlerElem.FieldByName(FieldBody)
 	hc.body = bodyOk
@@ -71,10 +160,23 @@ func (hc *handlerContext) checkBody(handlerElem reflect.Type) error {
 }
 
 func (hc *handlerContext) checkForm(handlerElem reflect.Type) error {
-	_, formOk := handlerElem.FieldByName(FieldForm)
+	form, formOk := handlerElem.FieldByName(FieldForm)
+	if formOk && form.Type.Kind() != reflect.Struct {
+		return errors.New("Form field added but it is not a struct")
+	}
+
+	if formOk && hasMultipartReader(form.Type) && form.Type.NumField() != 1 {
+		return errors.New("Form field has a *multipart.Reader, it cannot have other fields")
+	}
+
+	if formOk {
+		err := checkFileTags(form.Type)
+		if err != nil {
+			return err
+		}
+	}
 	hc.form = formOk
 	return nil
-
 }
 
 func (hc *handlerContext) checkRequestFields(handlerElem reflect.Type) error {
@@ -88,18 +190,161 @@ func (hc *handlerContext) checkRequestFields(handlerElem reflect.Type) error {
 		return err
 	}
 
-	// TODO: Add path field check to see whether path has those variables?
 	err = hc.checkParam(handlerElem)
 	if err != nil {
 		return err
 	}
 
 	err = hc.checkQuery(handlerElem)
-	return err
+	if err != nil {
+		return err
+	}
+
+	err = hc.checkHeader(handlerElem)
+	if err != nil {
+		return err
+	}
+
+	err = hc.checkCookie(handlerElem)
+	if err != nil {
+		return err
+	}
+
+	err = hc.checkBindableFields(handlerElem)
+	if err != nil {
+		return err
+	}
+
+	hc.compileBindings(handlerElem)
+	return nil
+}
+
+// compileBindings compiles the bindings of the special fields that are bound from the request, except the body
+func (hc *handlerContext) compileBindings(handlerElem reflect.Type) {
+	cs := hc.binder.converters
+	hc.paramPlan = cs.compileBinding(handlerElem, FieldParameter, PlaceParameter, "")
+	hc.queryPlan = cs.compileBinding(handlerElem, FieldQuery, PlaceQuery, TagQuery)
+	hc.headerPlan = cs.compileBinding(handlerElem, FieldHeader, PlaceHeader, TagHeader)
+	hc.cookiePlan = cs.compileBinding(handlerElem, FieldCookie, PlaceCookie, TagCookie)
+	hc.formPlan = cs.compileBinding(handlerElem, FieldForm, PlaceForm, "")
+}
+
+// checkParamPath checks whether each of the Param fields has a variable with the same name in the path
+func (hc *handlerContext) checkParamPath(handlerElem reflect.Type, path string) error {
+	if !hc.param {
+		return nil
+	}
+
+	variables := make(map[string]bool)
+	for _, name := range pathVariables(path) {
+		variables[name] = true
+	}
+
+	param, _ := handlerElem.FieldByName(FieldParameter)
+	for i := 0; i < param.Type.NumField(); i++ {
+		name := param.Type.Field(i).Name
+		if !variables[name] {
+			return fmt.Errorf("Param field %s of %s has no :%s in the path %s", name, hc.name, name, path)
+		}
+	}
+	return nil
+}
+
+// checkPathVariables checks whether each of the variables in the path has a Param field with the same name, it is
+// only checked for the handler of the route since the middlewares can use only some of them
+func (hc *handlerContext) checkPathVariables(path string) error {
+	if !hc.param {
+		return nil
+	}
+
+	param, _ := hc.tip.FieldByName(FieldParameter)
+	for _, name := range pathVariables(path) {
+		if _, ok := param.Type.FieldByName(name); !ok {
+			return fmt.Errorf("The variable %s in the path %s has no Param field in %s", name, path, hc.name)
+		}
+	}
+	return nil
+}
+
+// pathVariables returns the names of the :named and *catchAll variables in the path
+func pathVariables(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+// resolveInjections finds the provider of each of the injected fields with its type and key, so that they are not
+// looked up for each request. The fields that are set in the registered handler or tagged with `inject:"-"` are
+// configuration instead, which is copied to each request.
+func (hc *handlerContext) resolveInjections(handlerElem reflect.Type, injector *injector, handler interface{}) error {
+	// Take a copy so that the later changes to the registered handler do not affect the requests
+	hc.prototype = reflect.New(handlerElem).Elem()
+	hc.prototype.Set(reflect.ValueOf(handler).Elem())
+
+	for i := 0; i < handlerElem.NumField(); i++ {
+		field := handlerElem.Field(i)
+		if isSpecialField(field.Name) || field.PkgPath != "" {
+			continue
+		}
+
+		if field.Tag.Get(TagInject) == "-" || !hc.prototype.Field(i).IsZero() {
+			hc.configured = append(hc.configured, i)
+			continue
+		}
+
+		key, ok := field.Tag.Lookup(TagInject)
+		if !ok {
+			key = "default"
+		}
+
+		resolved, err := injector.resolve(field.Type, key)
+		if err != nil {
+			return fmt.Errorf("The field %s of %s cannot be injected, %w", field.Name, hc.name, err)
+		}
+
+		if resolved.custom != nil && resolved.custom.constructor != nil {
+			err := resolved.custom.constructor.check()
+			if err != nil {
+				return fmt.Errorf("The field %s of %s cannot be constructed: %w", field.Name, hc.name, err)
+			}
+		}
+
+		resolved.index = i
+		hc.injections = append(hc.injections, resolved)
+	}
+	return nil
+}
+
+func (hc *handlerContext) checkBindableFields(handlerElem reflect.Type) error {
+	// Slices and arrays can only be bound from the places that can have repeated keys
+	checks := []struct {
+		field string
+		multi bool
+	}{
+		{FieldParameter, false},
+		{FieldQuery, true},
+		{FieldHeader, true},
+		{FieldCookie, false},
+		{FieldForm, true},
+	}
+
+	for _, check := range checks {
+		err := hc.binder.converters.checkBindable(handlerElem, check.field, check.multi)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func transformRequestHandler(path string, method string, injector *injector, handler RequestHandler) (*handlerContext, error) {
-	rhc := handlerContext{}
+func transformRequestHandler(path string, method string, injector *injector, b *binder, handler RequestHandler) (*handlerContext, error) {
+	rhc := handlerContext{
+		binder: b,
+	}
 	// Handler parse parameters
 	handlerElem := reflect.TypeOf(handler).Elem()
 	rhc.name = fmt.Sprintf("%s.%s", handlerElem.PkgPath(), handlerElem.Name())
@@ -111,35 +356,42 @@ func transformRequestHandler(path string, method string, injector *injector, han
 		return nil, err
 	}
 
+	err = rhc.checkParamPath(handlerElem, path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = rhc.checkResponse(handlerElem, handler)
+	if err != nil {
+		return nil, err
+	}
+
 	if method == http.MethodGet {
 		if rhc.form || rhc.body {
 			return nil, errors.New("A GET request handler cannot have body or form")
 		}
 	}
 
-	for i := 0; i < handlerElem.NumField(); i++ {
-		name := handlerElem.Field(i).Name
-		if name == FieldBody || name == FieldForm || name == FieldQuery || name == FieldParameter {
-			continue
-		} else {
-			// TODO: Check if we can set it!, is the field exported?
-			rhc.injection = true
-			break
-		}
+	err = rhc.resolveInjections(handlerElem, injector, handler)
+	if err != nil {
+		return nil, err
 	}
 
-	rhc.RequestHandler = rhc.getMiddleRequestHandler(injector)
+	rhc.RequestHandler = rhc.getMiddleRequestHandler()
 	return &rhc, nil
 }
 
-func transformWebsocketHandler(path string, injector *injector, handler WebsocketHandler) (*handlerContext, error) {
+func transformWebsocketHandler(path string, injector *injector, b *binder, connections *wsConnections, handler WebsocketHandler) (*handlerContext, error) {
 	hc := &handlerContext{
-		websocket: true,
+		websocket:   true,
+		binder:      b,
+		connections: connections,
 	}
 
 	// Handler parse parameters
 	handlerElem := reflect.TypeOf(handler).Elem()
 
+	hc.name = fmt.Sprintf("%s.%s", handlerElem.PkgPath(), handlerElem.Name())
 	hc.tip = handlerElem
 
 	err := hc.checkRequestFields(handlerElem)
@@ -147,41 +399,70 @@ func transformWebsocketHandler(path string, injector *injector, handler Websocke
 		return nil, err
 	}
 
-	hc.RequestHandler = hc.getMiddleRequestHandler(injector)
+	err = hc.checkParamPath(handlerElem, path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = hc.checkPathVariables(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = hc.resolveInjections(handlerElem, injector, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	hc.RequestHandler = hc.getMiddleRequestHandler()
 	return hc, nil
 }
 
-func (hc *handlerContext) parseFields(c *Context, objElem reflect.Value, injector *injector) error {
+func (hc *handlerContext) parseFields(c *Context, objElem reflect.Value) error {
 	if hc.param {
-		err := c.parseParams(objElem)
+		err := c.parseParams(objElem, hc.paramPlan)
 		if err != nil {
 			return err
 		}
 	}
 
 	if hc.query {
-		err := c.parseQuery(objElem)
+		err := c.parseQuery(objElem, hc.queryPlan)
+		if err != nil {
+			return err
+		}
+	}
+
+	if hc.header {
+		err := c.parseHeaders(objElem, hc.headerPlan)
+		if err != nil {
+			return err
+		}
+	}
+
+	if hc.cookie {
+		err := c.parseCookies(objElem, hc.cookiePlan)
 		if err != nil {
 			return err
 		}
 	}
 
 	if hc.body {
-		err := c.parseBody(objElem)
+		err := c.parseBody(objElem, hc.binder)
 		if err != nil {
 			return err
 		}
 	}
 
 	if hc.form {
-		err := c.parseForm(objElem)
+		err := c.parseForm(objElem, hc.formPlan, hc.binder.maxMemory)
 		if err != nil {
 			return err
 		}
 	}
 
-	if hc.injection {
-		err := c.parseInjections(objElem, injector)
+	if len(hc.injections) > 0 {
+		err := c.parseInjections(objElem, hc.injections)
 		return err
 	}
 	return nil
@@ -205,6 +486,10 @@ func (hc *handlerContext) executeWebsocketHandler(obj reflect.Value, c *Context)
 	if err != nil {
 		return err
 	}
+	c.upgraded = true
+
+	hc.connections.add(conn)
+	defer hc.connections.remove(conn)
 
 	wsHandler.Handle(conn)
 	return nil
@@ -220,13 +505,16 @@ func (hc *handlerContext) executeRequestHandler(obj reflect.Value, c *Context) e
 	return reqHandler.Handle(c)
 }
 
-func (hc *handlerContext) getMiddleRequestHandler(injector *injector) middleRequestHandler {
+func (hc *handlerContext) getMiddleRequestHandler() middleRequestHandler {
 	// Create a new handler here
 	fn := func(c *Context) error {
 		obj := reflect.New(hc.tip)
 		objElem := obj.Elem()
+		for _, i := range hc.configured {
+			objElem.Field(i).Set(hc.prototype.Field(i))
+		}
 
-		err := hc.parseFields(c, objElem, injector)
+		err := hc.parseFields(c, objElem)
 		if err != nil {
 			return err
 		}
@@ -234,7 +522,12 @@ func (hc *handlerContext) getMiddleRequestHandler(injector *injector) middleRequ
 		if hc.websocket {
 			return hc.executeWebsocketHandler(obj, c)
 		}
-		return hc.executeRequestHandler(obj, c)
+
+		err = hc.executeRequestHandler(obj, c)
+		if err == nil && hc.response {
+			hc.setResponse(c, objElem)
+		}
+		return err
 
 	}
 	return fn
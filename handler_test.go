@@ -0,0 +1,141 @@
+package gongular
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pathCheckHandler struct {
+	Param struct {
+		UserID int
+		PostID int
+	}
+}
+
+func (p *pathCheckHandler) Handle(c *Context) error {
+	return nil
+}
+
+func TestRegistration_ParamPath(t *testing.T) {
+	hc, err := transformRequestHandler("/user/:UserID/post/:PostID", http.MethodGet, newInjector(), newBinder(), &pathCheckHandler{})
+	require.NoError(t, err)
+	assert.NoError(t, hc.checkPathVariables("/user/:UserID/post/:PostID"))
+
+	_, err = transformRequestHandler("/user/:UserID/post/:ID", http.MethodGet, newInjector(), newBinder(), &pathCheckHandler{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PostID")
+
+	hc, err = transformRequestHandler("/user/:UserID/post/:PostID/*rest", http.MethodGet, newInjector(), newBinder(), &pathCheckHandler{})
+	require.NoError(t, err)
+	err = hc.checkPathVariables("/user/:UserID/post/:PostID/*rest")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rest")
+}
+
+type nestedQueryHandler struct {
+	Query struct {
+		Page struct {
+			Number int
+			Size   int
+		}
+	}
+}
+
+func (n *nestedQueryHandler) Handle(c *Context) error {
+	return nil
+}
+
+func TestRegistration_NestedStruct(t *testing.T) {
+	_, err := transformRequestHandler("/", http.MethodGet, newInjector(), newBinder(), &nestedQueryHandler{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nested struct")
+}
+
+type missingProviderHandler struct {
+	DB      *sql.DB
+	Replica *sql.DB `inject:"replica"`
+	private int
+}
+
+func (m *missingProviderHandler) Handle(c *Context) error {
+	return nil
+}
+
+func TestRegistration_InjectionProvider(t *testing.T) {
+	inj := newInjector()
+	_, err := transformRequestHandler("/", http.MethodGet, inj, newBinder(), &missingProviderHandler{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DB")
+
+	inj.Provide(&sql.DB{}, "default")
+	_, err = transformRequestHandler("/", http.MethodGet, inj, newBinder(), &missingProviderHandler{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"replica"`)
+
+	inj.ProvideCustom(&sql.DB{}, func(c *Context) (interface{}, error) {
+		return &sql.DB{}, nil
+	}, "replica")
+	hc, err := transformRequestHandler("/", http.MethodGet, inj, newBinder(), &missingProviderHandler{})
+	require.NoError(t, err)
+	assert.Len(t, hc.injections, 2)
+}
+
+type configuredMiddleware struct {
+	Prefix  string
+	Limit   int  `inject:"-"`
+	Enabled bool `inject:"-"`
+	Query   struct {
+		Name string
+	}
+}
+
+func (m *configuredMiddleware) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%s%s:%d:%t", m.Prefix, m.Query.Name, m.Limit, m.Enabled))
+	return nil
+}
+
+func TestRegistration_ConfiguredFields(t *testing.T) {
+	e := newEngineTest()
+
+	hello := &configuredMiddleware{Prefix: "hello ", Enabled: true}
+	e.GetRouter().GET("/hello", hello)
+	e.GetRouter().GET("/bye", &configuredMiddleware{Prefix: "bye ", Limit: 60})
+
+	// The later changes do not affect the registered handler
+	hello.Prefix = "changed"
+
+	resp, content := get(t, e, "/hello?Name=mustafa")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"hello mustafa:0:true"`, content)
+
+	resp2, content2 := get(t, e, "/bye?Name=mustafa")
+	assert.Equal(t, http.StatusOK, resp2.Code)
+	assert.Equal(t, `"bye mustafa:60:false"`, content2)
+}
+
+func TestRegistration_ConfiguredFieldsNotInjected(t *testing.T) {
+	_, err := transformRequestHandler("/", http.MethodGet, newInjector(), newBinder(), &configuredMiddleware{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Prefix")
+}
+
+type rateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+func (r *rateLimit) Handle(c *Context) error {
+	return nil
+}
+
+func TestRegistration_ConfiguredZeroFields(t *testing.T) {
+	// A zero field is injected even if the others are configured, it needs the inject:"-" tag to be configuration
+	_, err := transformRequestHandler("/", http.MethodGet, newInjector(), newBinder(), &rateLimit{PerMinute: 60})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "The field Burst")
+}
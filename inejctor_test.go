@@ -1,12 +1,18 @@
 package gongular
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type injectionDirectHandler struct {
@@ -172,3 +178,418 @@ func TestInjectInterface(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.Code)
 	assert.Equal(t, `"25:5"`, content)
 }
+
+type injectUnassignable struct {
+	Dummy dummyInterface `inject:"key1"`
+}
+
+func (i *injectUnassignable) Handle(c *Context) error {
+	return nil
+}
+
+func TestInjectResolvedAtRegistration(t *testing.T) {
+	inj := newInjector()
+	inj.ProvideUnsafe("key1", 42)
+
+	_, err := transformRequestHandler("/", http.MethodGet, inj, newBinder(), &injectUnassignable{})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNoSuchDependency))
+
+	inj = newInjector()
+	inj.ProvideUnsafe("key1", getDummyInterface())
+	hc, err := transformRequestHandler("/", http.MethodGet, inj, newBinder(), &injectUnassignable{})
+	require.NoError(t, err)
+	require.Len(t, hc.injections, 1)
+	assert.Equal(t, 0, hc.injections[0].index)
+	assert.Nil(t, hc.injections[0].custom)
+}
+
+type scopedValue struct {
+	ID int32
+}
+
+type scopedHandler1 struct {
+	First  *scopedValue
+	Second *scopedValue `inject:"other"`
+}
+
+func (s *scopedHandler1) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%d:%d", s.First.ID, s.Second.ID))
+	return nil
+}
+
+type scopedHandler2 struct {
+	First *scopedValue
+}
+
+func (s *scopedHandler2) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%s:%d", c.body, s.First.ID))
+	return nil
+}
+
+func provideCounting(e *Engine, scope Scope) *int32 {
+	var counter int32
+	fn := func(c *Context) (interface{}, error) {
+		return &scopedValue{ID: atomic.AddInt32(&counter, 1)}, nil
+	}
+	e.ProvideScoped(&scopedValue{}, scope, fn)
+	e.ProvideScopedWithKey("other", &scopedValue{}, scope, fn)
+	return &counter
+}
+
+func TestInjectScope_Singleton(t *testing.T) {
+	e := newEngineTest()
+	counter := provideCounting(e, ScopeSingleton)
+	e.GetRouter().GET("/", &scopedHandler1{}, &scopedHandler2{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, _ := get(t, e, "/")
+			assert.Equal(t, http.StatusOK, resp.Code)
+		}()
+	}
+	wg.Wait()
+
+	// Each key has its own singleton
+	assert.Equal(t, int32(2), atomic.LoadInt32(counter))
+	_, content := get(t, e, "/")
+	assert.Contains(t, []string{`"1:2:1"`, `"2:1:2"`}, content)
+}
+
+func TestInjectScope_Request(t *testing.T) {
+	e := newEngineTest()
+	counter := provideCounting(e, ScopeRequest)
+	e.GetRouter().GET("/", &scopedHandler1{}, &scopedHandler2{})
+
+	_, content := get(t, e, "/")
+	assert.Equal(t, `"1:2:1"`, content)
+
+	_, content = get(t, e, "/")
+	assert.Equal(t, `"3:4:3"`, content)
+	assert.Equal(t, int32(4), *counter)
+}
+
+func TestInjectScope_Transient(t *testing.T) {
+	e := newEngineTest()
+	counter := provideCounting(e, ScopeTransient)
+	e.GetRouter().GET("/", &scopedHandler1{}, &scopedHandler2{})
+
+	_, content := get(t, e, "/")
+	assert.Equal(t, `"1:2:3"`, content)
+	assert.Equal(t, int32(3), *counter)
+}
+
+func TestInjectScope_SingletonRetriesOnError(t *testing.T) {
+	e := newEngineTest()
+	calls := 0
+	e.ProvideScoped(&scopedValue{}, ScopeSingleton, func(c *Context) (interface{}, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("not ready")
+		}
+		return &scopedValue{ID: 7}, nil
+	})
+	e.GetRouter().GET("/", &scopedHandler2{})
+
+	resp, _ := get(t, e, "/")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	resp, content := get(t, e, "/")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, content, ":7")
+
+	get(t, e, "/")
+	assert.Equal(t, 2, calls)
+}
+
+func TestInjectScope_SingletonDetached(t *testing.T) {
+	e := newEngineTest()
+	var created *Context
+	e.ProvideScoped(&scopedValue{}, ScopeSingleton, func(c *Context) (interface{}, error) {
+		created = c
+		c.Header("X-Singleton", "yes")
+		return &scopedValue{ID: 7}, nil
+	})
+	e.GetRouter().GET("/", &scopedHandler2{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	resp, _ := serve(t, e, req)
+	cancel()
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resp.Header().Get("X-Singleton"))
+
+	// The request has ended, but the context of the singleton is still alive
+	require.NotNil(t, created)
+	assert.NoError(t, created.Err())
+	assert.Equal(t, "/", created.Request().URL.Path)
+}
+
+type finalizedFailHandler struct {
+	Query struct {
+		Fail bool
+	}
+}
+
+func (f *finalizedFailHandler) Handle(c *Context) error {
+	if f.Query.Fail {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func provideFinalized(e *Engine, events *[]string, finalizeErr error) {
+	var counter int32
+	fn := func(c *Context) (interface{}, Finalizer, error) {
+		val := &scopedValue{ID: atomic.AddInt32(&counter, 1)}
+		return val, func(err error) error {
+			if err != nil {
+				*events = append(*events, fmt.Sprintf("rollback %d: %v", val.ID, err))
+			} else {
+				*events = append(*events, fmt.Sprintf("commit %d", val.ID))
+			}
+			return finalizeErr
+		}, nil
+	}
+	e.ProvideFinalized(&scopedValue{}, fn)
+	e.ProvideFinalizedWithKey("other", &scopedValue{}, fn)
+}
+
+func TestInjectFinalizer_Commit(t *testing.T) {
+	e := newEngineTest()
+	var events []string
+	provideFinalized(e, &events, nil)
+	e.GetRouter().GET("/", &scopedHandler1{}, &scopedHandler2{}, &finalizedFailHandler{})
+
+	resp, content := get(t, e, "/")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"1:2:1"`, content)
+
+	// The values are finalized once per request in the reverse order of their creation
+	assert.Equal(t, []string{"commit 2", "commit 1"}, events)
+}
+
+func TestInjectFinalizer_Rollback(t *testing.T) {
+	e := newEngineTest()
+	var events []string
+	provideFinalized(e, &events, nil)
+	e.GetRouter().GET("/", &scopedHandler1{}, &finalizedFailHandler{})
+
+	resp, _ := get(t, e, "/?Fail=true")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, []string{"rollback 2: failed", "rollback 1: failed"}, events)
+}
+
+func TestInjectFinalizer_Errors(t *testing.T) {
+	e := newEngineTest()
+	var events []string
+	provideFinalized(e, &events, errors.New("commit failed"))
+
+	var stat RouteStat
+	e.SetRouteCallback(func(s RouteStat) {
+		stat = s
+	})
+	e.GetRouter().GET("/", &scopedHandler1{})
+
+	// The handler has succeeded, but the response cannot be if the values are not committed
+	resp, content := get(t, e, "/")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, `"commit failed"`, content)
+	require.Len(t, stat.FinalizerErrors, 2)
+	assert.EqualError(t, stat.FinalizerErrors[0], "commit failed")
+	assert.Contains(t, stat.Logs.String(), "commit failed")
+}
+
+type finalizedPanicHandler struct {
+	First     *scopedValue
+	Panicking *scopedValue `inject:"panicking"`
+}
+
+func (f *finalizedPanicHandler) Handle(c *Context) error {
+	return nil
+}
+
+func TestInjectFinalizer_Panic(t *testing.T) {
+	e := newEngineTest()
+	var events []string
+	provideFinalized(e, &events, nil)
+	e.ProvideFinalizedWithKey("panicking", &scopedValue{}, func(c *Context) (interface{}, Finalizer, error) {
+		return &scopedValue{}, func(err error) error {
+			panic("oops")
+		}, nil
+	})
+
+	var stat RouteStat
+	e.SetRouteCallback(func(s RouteStat) {
+		stat = s
+	})
+	e.GetRouter().GET("/", &finalizedPanicHandler{})
+
+	resp, _ := get(t, e, "/")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	// The other finalizers still run
+	assert.Equal(t, []string{"commit 1"}, events)
+	require.Len(t, stat.FinalizerErrors, 1)
+	assert.EqualError(t, stat.FinalizerErrors[0], "panic: oops")
+}
+
+func TestInjectFinalizer_Execute(t *testing.T) {
+	e := newEngineTest()
+	var events []string
+	provideFinalized(e, &events, errors.New("commit failed"))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := e.NewContext("/", httptest.NewRecorder(), req, nil)
+	err := e.Execute(c, &scopedHandler2{})
+	assert.EqualError(t, err, "commit failed")
+	assert.Equal(t, []string{"commit 1"}, events)
+}
+
+type otherDummyImpl struct{}
+
+func (o *otherDummyImpl) getId() int {
+	return 1
+}
+
+func TestInjectInterface_Generic(t *testing.T) {
+	e := newEngineTest()
+	Provide[dummyInterface](e, dummyInterfaceImpl{id: 2}, "key1")
+	Provide[dummyInterface](e, &otherDummyImpl{}, "key2")
+
+	e.GetRouter().GET("/:UserID", &injectionInterfaceHandler{})
+
+	resp, content := get(t, e, "/5")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"10:5"`, content)
+}
+
+func TestInjectInterface_Implementation(t *testing.T) {
+	e := newEngineTest()
+	inj := e.injector
+	inj.Provide(dummyInterfaceImpl{id: 3}, "key1")
+	inj.Provide(&otherDummyImpl{}, "default")
+
+	// The only implementation with the key is injected
+	hc, err := transformRequestHandler("/:UserID", http.MethodGet, inj, newBinder(), &injectionInterfaceHandler{})
+	require.NoError(t, err)
+	require.Len(t, hc.injections, 1)
+	assert.Equal(t, dummyInterfaceImpl{id: 3}, hc.injections[0].value.Interface())
+
+	inj.ProvideCustom(&otherDummyImpl{}, func(c *Context) (interface{}, error) {
+		return &otherDummyImpl{}, nil
+	}, "key1")
+	_, err = transformRequestHandler("/:UserID", http.MethodGet, inj, newBinder(), &injectionInterfaceHandler{})
+	require.True(t, errors.Is(err, ErrAmbiguousDependency))
+	assert.Contains(t, err.Error(), "*gongular.otherDummyImpl, gongular.dummyInterfaceImpl")
+
+	// The interface provided as itself has the precedence
+	Provide[dummyInterface](e, dummyInterfaceImpl{id: 4}, "key1")
+	hc, err = transformRequestHandler("/:UserID", http.MethodGet, inj, newBinder(), &injectionInterfaceHandler{})
+	require.NoError(t, err)
+	assert.Equal(t, dummyInterfaceImpl{id: 4}, hc.injections[0].value.Interface())
+}
+
+type childInjectionHandler struct {
+	Value *scopedValue
+	Other *scopedValue `inject:"other"`
+}
+
+func (h *childInjectionHandler) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%d:%d", h.Value.ID, h.Other.ID))
+	return nil
+}
+
+func TestInjectChild(t *testing.T) {
+	e := newEngineTest()
+	e.Provide(&scopedValue{ID: 1})
+	e.CustomProvideWithKey("other", &scopedValue{}, func(c *Context) (interface{}, error) {
+		return &scopedValue{ID: 2}, nil
+	})
+
+	r := e.GetRouter()
+	r.GET("/", &childInjectionHandler{})
+
+	reports := r.Group("/reports").WithProviders(func(p *Providers) {
+		p.Provide(&scopedValue{ID: 3})
+	})
+	reports.GET("/", &childInjectionHandler{})
+
+	v2 := reports.Group("/v2").WithProviders(func(p *Providers) {
+		p.CustomProvideWithKey("other", &scopedValue{}, func(c *Context) (interface{}, error) {
+			return &scopedValue{ID: 4}, nil
+		})
+	})
+	v2.GET("/", &childInjectionHandler{})
+	v2.Group("/nested").GET("/", &childInjectionHandler{})
+
+	tests := map[string]string{
+		"/":                  `"1:2"`,
+		"/reports":           `"3:2"`,
+		"/reports/v2":        `"3:4"`,
+		"/reports/v2/nested": `"3:4"`,
+	}
+	for path, expected := range tests {
+		resp, content := get(t, e, path)
+		assert.Equal(t, http.StatusOK, resp.Code, path)
+		assert.Equal(t, expected, content, path)
+	}
+}
+
+func TestInjectChild_Constructor(t *testing.T) {
+	e := newEngineTest()
+	e.Provide(&ctorConfig{Name: "primary"})
+	e.ProvideWithKey("replica", &ctorConfig{Name: "replica"})
+	e.ProvideConstructor(func(deps struct {
+		Config  *ctorConfig
+		Replica *ctorConfig `inject:"replica"`
+	}) *ctorRepository {
+		return &ctorRepository{Config: deps.Config, Replica: deps.Replica}
+	})
+
+	r := e.GetRouter().Group("/tenant").WithProviders(func(p *Providers) {
+		p.Provide(&ctorConfig{Name: "tenant"})
+		p.ProvideConstructor(func(repo *ctorRepository) *ctorService {
+			return &ctorService{Repository: repo}
+		})
+	})
+	r.GET("/", &ctorHandler{})
+	require.NoError(t, e.Validate())
+
+	// The constructor of the parent resolves its parameters from the parent
+	resp, content := get(t, e, "/tenant")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"primary:replica"`, content)
+
+	// The engine's routes cannot see the values of the child
+	e.SetFatalRegistration(false)
+	e.GetRouter().GET("/", &ctorHandler{})
+	err := e.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no provider for the type *gongular.ctorService")
+}
+
+func TestInjectOverride(t *testing.T) {
+	e := newEngineTest()
+	e.OverrideProviders(func(p *Providers) {
+		p.Provide(&scopedValue{ID: 9})
+	})
+	e.Provide(&scopedValue{ID: 1})
+	e.ProvideWithKey("other", &scopedValue{ID: 2})
+
+	e.GetRouter().GET("/", &childInjectionHandler{})
+	e.GetRouter().Group("/child").WithProviders(func(p *Providers) {
+		p.Provide(&scopedValue{ID: 3})
+		p.ProvideWithKey("other", &scopedValue{ID: 4})
+	}).GET("/", &childInjectionHandler{})
+
+	_, content := get(t, e, "/")
+	assert.Equal(t, `"9:2"`, content)
+
+	_, content = get(t, e, "/child")
+	assert.Equal(t, `"9:4"`, content)
+}
@@ -3,6 +3,25 @@ package gongular
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Scope defines how long a value created by a CustomProvideFunction lives
+type Scope int
+
+const (
+	// ScopeRequest creates the value once per request, and it is shared by the handlers of the request. It is the
+	// scope of CustomProvide.
+	ScopeRequest Scope = iota
+	// ScopeSingleton creates the value once, when it is first needed, and shares it with all requests. The function
+	// gets a Context that is detached from the request, so it is not canceled when the request ends, and its status,
+	// headers and body are not written to the response.
+	ScopeSingleton
+	// ScopeTransient creates a new value for each field it is injected to
+	ScopeTransient
 )
 
 // injector remembers the provided values so that you can inject whenever
@@ -10,7 +29,70 @@ import (
 type injector struct {
 	unsafeValues    map[string]reflect.Value
 	values          map[reflect.Type]map[string]interface{}
-	customProviders map[reflect.Type]map[string]CustomProvideFunction
+	customProviders map[reflect.Type]map[string]*customProvider
+
+	// The constructors in the order they are provided, they are also kept as custom providers
+	constructors []*constructor
+
+	// The injector whose values are shadowed by this one, and the child injectors of the groups
+	parent   *injector
+	children []*injector
+
+	// The values of the engine that shadow all the others, see Engine.OverrideProviders
+	overrides *injector
+}
+
+// customProvider is a CustomProvideFunction with the scope of the values it creates
+type customProvider struct {
+	fn    CustomProvideFunction
+	scope Scope
+
+	// The function that also returns a Finalizer, if it is provided with ProvideFinalized
+	finalized FinalizedProvideFunction
+
+	// The constructor whose dependencies are resolved from the injector, if it is provided with ProvideConstructor
+	constructor *constructor
+
+	// The value of a singleton, created is set atomically after the value
+	mu      sync.Mutex
+	created uint32
+	value   interface{}
+}
+
+// create calls the function of the provider, and adds the Finalizer of the value to the context if there is any
+func (p *customProvider) create(c *Context) (interface{}, error) {
+	if p.finalized == nil {
+		return p.fn(c)
+	}
+
+	val, finalizer, err := p.finalized(c)
+	if err == nil && finalizer != nil {
+		c.finalizers = append(c.finalizers, finalizer)
+	}
+	return val, err
+}
+
+// singleton returns the value that is created once, the function is called again if it fails
+func (p *customProvider) singleton(c *Context) (interface{}, error) {
+	if atomic.LoadUint32(&p.created) == 1 {
+		return p.value, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.created == 1 {
+		return p.value, nil
+	}
+
+	// The value outlives the request, so it must not be bound to it
+	val, err := p.fn(c.detached())
+	if err != nil {
+		return nil, err
+	}
+
+	p.value = val
+	atomic.StoreUint32(&p.created, 1)
+	return val, nil
 }
 
 // newInjector creates an Injector with its initial structures initialized
@@ -18,13 +100,25 @@ func newInjector() *injector {
 	return &injector{
 		unsafeValues:    make(map[string]reflect.Value),
 		values:          make(map[reflect.Type]map[string]interface{}),
-		customProviders: make(map[reflect.Type]map[string]CustomProvideFunction),
+		customProviders: make(map[reflect.Type]map[string]*customProvider),
 	}
 }
 
+// child creates an injector whose values shadow the ones of this injector with the same type and key
+func (inj *injector) child() *injector {
+	child := newInjector()
+	child.parent = inj
+	inj.children = append(inj.children, child)
+	return child
+}
+
 // Provide registers given value depending on its name
 func (inj *injector) Provide(value interface{}, key string) {
-	tip := reflect.TypeOf(value)
+	inj.provideAs(reflect.TypeOf(value), value, key)
+}
+
+// provideAs registers given value as the type depending on its name
+func (inj *injector) provideAs(tip reflect.Type, value interface{}, key string) {
 	if inj.values[tip] == nil {
 		inj.values[tip] = make(map[string]interface{})
 	}
@@ -43,32 +137,284 @@ func (inj *injector) ProvideUnsafe(key string, value interface{}) {
 // ProvideCustom gets the type information from value, however calls CustomProvideFunction
 // each time to provide when needed
 func (inj *injector) ProvideCustom(value interface{}, fn CustomProvideFunction, key string) {
+	inj.ProvideScoped(value, fn, key, ScopeRequest)
+}
+
+// ProvideScoped is like ProvideCustom, but the CustomProvideFunction is called depending on the scope
+func (inj *injector) ProvideScoped(value interface{}, fn CustomProvideFunction, key string, scope Scope) {
 	tip := reflect.TypeOf(value)
 	if inj.customProviders[tip] == nil {
-		inj.customProviders[tip] = make(map[string]CustomProvideFunction)
+		inj.customProviders[tip] = make(map[string]*customProvider)
+	}
+
+	inj.customProviders[tip][key] = &customProvider{
+		fn:    fn,
+		scope: scope,
+	}
+}
+
+// ProvideFinalized is like ProvideCustom, but the Finalizer returned by the FinalizedProvideFunction is called when
+// the request is finished
+func (inj *injector) ProvideFinalized(value interface{}, fn FinalizedProvideFunction, key string) {
+	tip := reflect.TypeOf(value)
+	if inj.customProviders[tip] == nil {
+		inj.customProviders[tip] = make(map[string]*customProvider)
+	}
+
+	inj.customProviders[tip][key] = &customProvider{
+		scope:     ScopeRequest,
+		finalized: fn,
+	}
+}
+
+// ProvideConstructor registers the function as a singleton provider of the type it returns, its parameters are
+// resolved from the injector when the value is first needed
+func (inj *injector) ProvideConstructor(fn interface{}, key string) {
+	ctor := newConstructor(fn, key)
+	ctor.injector = inj
+	if inj.customProviders[ctor.tip] == nil {
+		inj.customProviders[ctor.tip] = make(map[string]*customProvider)
+	}
+
+	p := &customProvider{
+		scope:       ScopeSingleton,
+		constructor: ctor,
+	}
+	p.fn = ctor.call
+
+	inj.customProviders[ctor.tip][key] = p
+	inj.constructors = append(inj.constructors, ctor)
+}
+
+// resolvedInjection is the provider of an injected field of a handler, which is found when the handler is registered
+type resolvedInjection struct {
+	index  int
+	tip    reflect.Type
+	key    string
+	value  reflect.Value
+	custom *customProvider
+}
+
+// resolve finds the provider of a value of the type with the key, the unsafe values come first, then the directly
+// provided ones and the custom ones, and then the ones of the parent injector. The overrides precede all of them.
+// An interface falls back to the only provided type that implements it.
+func (inj *injector) resolve(tip reflect.Type, key string) (resolvedInjection, error) {
+	resolved := resolvedInjection{
+		tip: tip,
+		key: key,
+	}
+
+	for _, cur := range inj.levels() {
+		if uval, ok := cur.unsafeValues[key]; ok {
+			if !uval.Type().AssignableTo(tip) {
+				return resolved, fmt.Errorf("the unsafe value with the key %q is a %s, not a %s: %w",
+					key, uval.Type(), tip, ErrNoSuchDependency)
+			}
+			resolved.value = uval
+			return resolved, nil
+		} else if cur.resolveProvider(&resolved, tip, key) {
+			return resolved, nil
+		}
 	}
 
-	inj.customProviders[tip][key] = fn
+	if tip.Kind() == reflect.Interface {
+		implementations := inj.implementations(tip, key)
+		if len(implementations) == 1 {
+			return inj.resolve(implementations[0], key)
+		} else if len(implementations) > 1 {
+			names := make([]string, len(implementations))
+			for i, impl := range implementations {
+				names[i] = impl.String()
+			}
+			return resolved, fmt.Errorf("the types %s implement %s with the key %q: %w",
+				strings.Join(names, ", "), tip, key, ErrAmbiguousDependency)
+		}
+	}
+	return resolved, fmt.Errorf("no provider for the type %s with the key %q: %w", tip, key, ErrNoSuchDependency)
 }
 
-// GetDirectValue returns the directly provided dependency
-func (inj *injector) GetDirectValue(tip reflect.Type, key string) (interface{}, bool) {
-	// TODO: Avoid nil
-	val, ok := inj.values[tip][key]
-	return val, ok
+// levels returns the injectors to look up in order, the overrides of the engine come first, then this injector and
+// its parents
+func (inj *injector) levels() []*injector {
+	root := inj
+	for root.parent != nil {
+		root = root.parent
+	}
+
+	var levels []*injector
+	if root.overrides != nil && root.overrides != inj {
+		levels = append(levels, root.overrides)
+	}
+	for cur := inj; cur != nil; cur = cur.parent {
+		levels = append(levels, cur)
+	}
+	return levels
+}
+
+// resolveProvider finds the value or the custom provider that is provided exactly with the type and the key
+func (inj *injector) resolveProvider(resolved *resolvedInjection, tip reflect.Type, key string) bool {
+	if val, ok := inj.values[tip][key]; ok {
+		resolved.value = reflect.ValueOf(val)
+		return true
+	} else if p, ok := inj.customProviders[tip][key]; ok {
+		resolved.custom = p
+		return true
+	}
+	return false
+}
+
+// implementations returns the provided types with the key that implement the interface, including the ones of the
+// parent injectors, sorted by their names
+func (inj *injector) implementations(iface reflect.Type, key string) []reflect.Type {
+	found := make(map[reflect.Type]bool)
+	for _, cur := range inj.levels() {
+		for tip, values := range cur.values {
+			if _, ok := values[key]; ok && tip.Implements(iface) {
+				found[tip] = true
+			}
+		}
+		for tip, providers := range cur.customProviders {
+			if _, ok := providers[key]; ok && tip.Implements(iface) {
+				found[tip] = true
+			}
+		}
+	}
+
+	implementations := make([]reflect.Type, 0, len(found))
+	for tip := range found {
+		implementations = append(implementations, tip)
+	}
+	sort.Slice(implementations, func(i, j int) bool {
+		return implementations[i].String() < implementations[j].String()
+	})
+	return implementations
 }
 
-// GetCustomValue returns the CustomProvideFunction for the requested dependency
-func (inj *injector) GetCustomValue(tip reflect.Type, key string) (CustomProvideFunction, bool) {
-	val, ok := inj.customProviders[tip][key]
-	return val, ok
+// set injects the value to the field of the handler object
+func (ri resolvedInjection) set(c *Context, obj reflect.Value) error {
+	val, err := ri.get(c)
+	if err != nil {
+		return err
+	}
+
+	obj.Field(ri.index).Set(val)
+	return nil
 }
 
-func (inj *injector) GetUnsafeValue(key string) (reflect.Value, bool) {
-	val, ok := inj.unsafeValues[key]
-	return val, ok
+// get returns the provided value, the custom providers are called depending on their scope
+func (ri resolvedInjection) get(c *Context) (reflect.Value, error) {
+	if ri.custom == nil {
+		return ri.value, nil
+	}
+
+	var val interface{}
+	var err error
+	switch ri.custom.scope {
+	case ScopeSingleton:
+		val, err = ri.custom.singleton(c)
+	case ScopeTransient:
+		val, err = ri.custom.create(c)
+	default:
+		cached, ok := c.getCachedInjection(ri.tip, ri.key)
+		if ok {
+			return reflect.ValueOf(cached), nil
+		}
+
+		val, err = ri.custom.create(c)
+		if err == nil {
+			c.putCachedInjection(ri.tip, ri.key, val)
+		}
+	}
+
+	if err != nil {
+		return reflect.Value{}, InjectionError{
+			Key:             ri.key,
+			Tip:             ri.tip,
+			UnderlyingError: err,
+		}
+	}
+
+	// A constructor can return a nil interface
+	if val == nil {
+		return reflect.Zero(ri.tip), nil
+	}
+	return reflect.ValueOf(val), nil
 }
 
 // CustomProvideFunction is called whenever a value is needed to be provided
 // with custom logic
 type CustomProvideFunction func(c *Context) (interface{}, error)
+
+// Finalizer releases a value created by a FinalizedProvideFunction when the request is finished, i.e. commits or
+// rolls back a transaction. err is the error that ended the handler chain, or nil if all the handlers succeeded.
+type Finalizer func(err error) error
+
+// FinalizedProvideFunction is a CustomProvideFunction that also returns a Finalizer for the value, which can be nil
+type FinalizedProvideFunction func(c *Context) (interface{}, Finalizer, error)
+
+// Providers provides the values to the child injector of a router, see Router.WithProviders
+type Providers struct {
+	injector *injector
+}
+
+// Provide provides with "default" key
+func (p *Providers) Provide(value interface{}) {
+	p.injector.Provide(value, "default")
+}
+
+// ProvideWithKey provides an interface with a key
+func (p *Providers) ProvideWithKey(key string, value interface{}) {
+	p.injector.Provide(value, key)
+}
+
+// ProvideAs provides the value as the type T with a key, like Provide does for the engine
+func ProvideAs[T any](p *Providers, value T, key string) {
+	p.injector.provideAs(reflect.TypeOf((*T)(nil)).Elem(), value, key)
+}
+
+// ProvideUnsafe provides a key with an exact value
+func (p *Providers) ProvideUnsafe(key string, value interface{}) {
+	p.injector.ProvideUnsafe(key, value)
+}
+
+// CustomProvide provides with "default" key by calling the supplied CustomProvideFunction each time
+func (p *Providers) CustomProvide(value interface{}, fn CustomProvideFunction) {
+	p.injector.ProvideCustom(value, fn, "default")
+}
+
+// CustomProvideWithKey provides with a key by calling the supplied CustomProvideFunction each time
+func (p *Providers) CustomProvideWithKey(key string, value interface{}, fn CustomProvideFunction) {
+	p.injector.ProvideCustom(value, fn, key)
+}
+
+// ProvideScoped provides with "default" key by calling the supplied CustomProvideFunction depending on the scope
+func (p *Providers) ProvideScoped(value interface{}, scope Scope, fn CustomProvideFunction) {
+	p.injector.ProvideScoped(value, fn, "default", scope)
+}
+
+// ProvideScopedWithKey provides with a key by calling the supplied CustomProvideFunction depending on the scope
+func (p *Providers) ProvideScopedWithKey(key string, value interface{}, scope Scope, fn CustomProvideFunction) {
+	p.injector.ProvideScoped(value, fn, key, scope)
+}
+
+// ProvideFinalized provides with "default" key by calling the supplied FinalizedProvideFunction once per request
+func (p *Providers) ProvideFinalized(value interface{}, fn FinalizedProvideFunction) {
+	p.injector.ProvideFinalized(value, fn, "default")
+}
+
+// ProvideFinalizedWithKey provides with a key by calling the supplied FinalizedProvideFunction once per request
+func (p *Providers) ProvideFinalizedWithKey(key string, value interface{}, fn FinalizedProvideFunction) {
+	p.injector.ProvideFinalized(value, fn, key)
+}
+
+// ProvideConstructor provides the value returned by the function with "default" key, whose parameters are resolved
+// from the child injector first
+func (p *Providers) ProvideConstructor(fn interface{}) {
+	p.injector.ProvideConstructor(fn, "default")
+}
+
+// ProvideConstructorWithKey provides the value returned by the function with a key, whose parameters are resolved
+// from the child injector first
+func (p *Providers) ProvideConstructorWithKey(key string, fn interface{}) {
+	p.injector.ProvideConstructor(fn, key)
+}
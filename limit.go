@@ -0,0 +1,58 @@
+package gongular
+
+import (
+	"io"
+)
+
+// SetMaxBodySize sets the maximum size of the request bodies in bytes for all routes, unless a router overrides it
+// with WithMaxBodySize. Zero means unlimited, which is the default.
+func (e *Engine) SetMaxBodySize(n int64) {
+	e.maxBodySize = n
+}
+
+// SetStrictJSON sets whether the JSON request bodies are decoded strictly, which rejects the unknown fields and the
+// data after the JSON value
+func (e *Engine) SetStrictJSON(strict bool) {
+	e.binder.strictJSON = strict
+}
+
+// limitedBody wraps a request body and fails with BodyTooLargeError when more than limit bytes are read from it
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+	err       error
+}
+
+func newLimitedBody(body io.ReadCloser, limit int64) *limitedBody {
+	return &limitedBody{
+		ReadCloser: body,
+		limit:      limit,
+		remaining:  limit,
+	}
+}
+
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+
+	// Read one more byte than allowed to detect whether the body exceeds the limit
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.ReadCloser.Read(p)
+	if int64(n) <= l.remaining {
+		l.remaining -= int64(n)
+		return n, err
+	}
+
+	n = int(l.remaining)
+	l.remaining = 0
+	l.err = BodyTooLargeError{
+		Limit:  l.limit,
+		Reason: "The request body is larger than the allowed size",
+	}
+	return n, l.err
+}
@@ -0,0 +1,60 @@
+package gongular
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBodySize_Engine(t *testing.T) {
+	e := newEngineTest()
+	e.SetMaxBodySize(16)
+	e.GetRouter().POST("/track", &bodyTest{})
+
+	resp, _ := post(t, e, "/track", map[string]interface{}{"Name": "m"})
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp2, content2 := post(t, e, "/track", map[string]interface{}{"Name": strings.Repeat("m", 32)})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp2.Code)
+	assert.Contains(t, content2, `"Limit": 16`)
+}
+
+func TestMaxBodySize_GroupAndRoute(t *testing.T) {
+	e := newEngineTest()
+	e.SetMaxBodySize(16)
+
+	g := e.GetRouter().Group("/big").WithMaxBodySize(1024)
+	g.POST("/track", &bodyTest{})
+	e.GetRouter().WithMaxBodySize(8).POST("/form", &formHandler{})
+
+	resp, _ := post(t, e, "/big/track", map[string]interface{}{"Name": strings.Repeat("m", 32)})
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp2, content2 := postForm(t, e, "/form", url.Values{"Name": []string{strings.Repeat("m", 32)}})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp2.Code)
+	assert.Contains(t, content2, `"Limit": 8`)
+}
+
+func TestStrictJSON(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/track", &bodyTest{})
+
+	resp, _ := respWrap(t, e, "/track", http.MethodPost, strings.NewReader(`{"Name": "m", "Unknown": 1}`))
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	e.SetStrictJSON(true)
+
+	resp2, content2 := respWrap(t, e, "/track", http.MethodPost, strings.NewReader(`{"Name": "m", "Unknown": 1}`))
+	assert.Equal(t, http.StatusBadRequest, resp2.Code)
+	assert.Contains(t, content2, "Unknown")
+
+	resp3, content3 := respWrap(t, e, "/track", http.MethodPost, strings.NewReader(`{"Name": "m"} {"Name": "n"}`))
+	assert.Equal(t, http.StatusBadRequest, resp3.Code)
+	assert.Contains(t, content3, "unexpected data after the JSON value")
+
+	resp4, _ := respWrap(t, e, "/track", http.MethodPost, strings.NewReader("{\"Name\": \"m\"}\n"))
+	assert.Equal(t, http.StatusOK, resp4.Code)
+}
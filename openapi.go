@@ -0,0 +1,562 @@
+package gongular
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification the generated documents conform to
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIInfo is the metadata about the API in the generated document
+type OpenAPIInfo struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+// OpenAPIDocument is the root of an OpenAPI document generated from the registered routes
+type OpenAPIDocument struct {
+	OpenAPI string                                  `json:"openapi" yaml:"openapi"`
+	Info    OpenAPIInfo                             `json:"info" yaml:"info"`
+	Paths   map[string]map[string]*OpenAPIOperation `json:"paths" yaml:"paths"`
+
+	// The schemas of the recursive types, which are referred to with $ref
+	Components *OpenAPIComponents `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+// OpenAPIComponents holds the schemas that are referred to from the other schemas of the document
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas" yaml:"schemas"`
+}
+
+// OpenAPIOperation describes a single method on a path
+type OpenAPIOperation struct {
+	Parameters  []*OpenAPIParameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses" yaml:"responses"`
+}
+
+// OpenAPIParameter describes a path, query, header or cookie parameter of an operation
+type OpenAPIParameter struct {
+	Name     string         `json:"name" yaml:"name"`
+	In       string         `json:"in" yaml:"in"`
+	Required bool           `json:"required,omitempty" yaml:"required,omitempty"`
+	Style    string         `json:"style,omitempty" yaml:"style,omitempty"`
+	Explode  *bool          `json:"explode,omitempty" yaml:"explode,omitempty"`
+	Schema   *OpenAPISchema `json:"schema" yaml:"schema"`
+}
+
+// OpenAPIRequestBody describes the body of an operation per content type
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]*OpenAPIMediaType `json:"content" yaml:"content"`
+}
+
+// OpenAPIResponse describes a response of an operation
+type OpenAPIResponse struct {
+	Description string                       `json:"description" yaml:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// OpenAPIMediaType holds the schema for a content type
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema" yaml:"schema"`
+}
+
+// OpenAPISchema is the subset of JSON Schema that can be derived from Go types and govalidator tags
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                    `json:"format,omitempty" yaml:"format,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty" yaml:"required,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+}
+
+// JSON serializes the document as indented JSON
+func (d *OpenAPIDocument) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// YAML serializes the document as YAML
+func (d *OpenAPIDocument) YAML() ([]byte, error) {
+	return yaml.Marshal(d)
+}
+
+// OpenAPI generates an OpenAPI document from the HTTP routes registered so far, using the Param, Query, Header,
+// Cookie, Body and Form fields of their handlers and the govalidator tags on them
+func (e *Engine) OpenAPI(info OpenAPIInfo) *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+
+	schemas := newOpenAPISchemas()
+	for _, rt := range e.routes {
+		p := openAPIPath(rt.path)
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = make(map[string]*OpenAPIOperation)
+		}
+		doc.Paths[p][strings.ToLower(rt.method)] = rt.openAPIOperation(schemas)
+	}
+
+	if len(schemas.components) > 0 {
+		doc.Components = &OpenAPIComponents{Schemas: schemas.components}
+	}
+	return doc
+}
+
+// ServeOpenAPI serves the OpenAPI document of the engine as JSON at the given path. The document is generated on
+// each request, so the routes registered later are included as well.
+func (e *Engine) ServeOpenAPI(path string, info OpenAPIInfo) {
+	e.actualRouter.GET(path, func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		b, err := e.OpenAPI(info).JSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-type", "application/json")
+		w.Write(b)
+	})
+}
+
+// openAPIPath converts the httprouter :param and *catchall segments to OpenAPI {param} templates
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (rt *route) openAPIOperation(schemas *openAPISchemas) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		Responses: map[string]*OpenAPIResponse{
+			strconv.Itoa(http.StatusOK): {Description: http.StatusText(http.StatusOK)},
+		},
+	}
+
+	seen := make(map[string]bool)
+	addParameter := func(p *OpenAPIParameter) {
+		if seen[p.In+":"+p.Name] {
+			return
+		}
+		seen[p.In+":"+p.Name] = true
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	binds := false
+	for _, hc := range rt.handlers {
+		if hc.param {
+			binds = true
+			for _, p := range schemas.parameters(hc.tip, FieldParameter, "path", "") {
+				// Path parameters are always required
+				p.Required = true
+				addParameter(p)
+			}
+		}
+
+		if hc.query {
+			binds = true
+			for _, p := range schemas.parameters(hc.tip, FieldQuery, "query", TagQuery) {
+				addParameter(p)
+			}
+		}
+
+		if hc.header {
+			binds = true
+			for _, p := range schemas.parameters(hc.tip, FieldHeader, "header", TagHeader) {
+				addParameter(p)
+			}
+		}
+
+		if hc.cookie {
+			binds = true
+			for _, p := range schemas.parameters(hc.tip, FieldCookie, "cookie", TagCookie) {
+				addParameter(p)
+			}
+		}
+
+		if hc.body {
+			binds = true
+			field, _ := hc.tip.FieldByName(FieldBody)
+			op.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content: map[string]*OpenAPIMediaType{
+					"application/json": {Schema: schemas.schema(field.Type, "json")},
+				},
+			}
+		}
+
+		if hc.form {
+			binds = true
+			field, _ := hc.tip.FieldByName(FieldForm)
+			contentType := "application/x-www-form-urlencoded"
+			if hasUploadedFile(field.Type) || hasMultipartReader(field.Type) {
+				contentType = "multipart/form-data"
+			}
+
+			// The parts of a streamed form are not known
+			schema := &OpenAPISchema{Type: "object"}
+			if !hasMultipartReader(field.Type) {
+				schema = schemas.schema(field.Type, "")
+			}
+
+			op.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content: map[string]*OpenAPIMediaType{
+					contentType: {Schema: schema},
+				},
+			}
+		}
+	}
+
+	if binds {
+		op.Responses[strconv.Itoa(http.StatusBadRequest)] = &OpenAPIResponse{
+			Description: "The request could not be parsed or validated",
+		}
+	}
+
+	responses := rt.describeResponses()
+	if len(responses) > 0 && responses[0].Status >= 200 && responses[0].Status < 300 {
+		// The declared success response replaces the default one
+		delete(op.Responses, strconv.Itoa(http.StatusOK))
+	}
+
+	for _, desc := range responses {
+		response := &OpenAPIResponse{
+			Description: desc.Description,
+		}
+		if response.Description == "" {
+			response.Description = http.StatusText(desc.Status)
+		}
+
+		if desc.Body != nil {
+			response.Content = map[string]*OpenAPIMediaType{
+				"application/json": {Schema: schemas.schema(reflect.TypeOf(desc.Body), "json")},
+			}
+		}
+		op.Responses[strconv.Itoa(desc.Status)] = response
+	}
+	return op
+}
+
+// openAPISchemas derives the schemas of a document, it keeps the struct types that are being derived so that a
+// recursive type is put to the components and referred to with $ref instead of being expanded forever
+type openAPISchemas struct {
+	visiting   map[reflect.Type]bool
+	inlining   map[reflect.Type]bool
+	recursive  map[reflect.Type]bool
+	components map[string]*OpenAPISchema
+
+	// The names of the types in the components, and the types by their names
+	names map[reflect.Type]string
+	types map[string]reflect.Type
+}
+
+func newOpenAPISchemas() *openAPISchemas {
+	return &openAPISchemas{
+		visiting:   make(map[reflect.Type]bool),
+		inlining:   make(map[reflect.Type]bool),
+		recursive:  make(map[reflect.Type]bool),
+		components: make(map[string]*OpenAPISchema),
+		names:      make(map[reflect.Type]string),
+		types:      make(map[string]reflect.Type),
+	}
+}
+
+// componentName returns the name of the type in the components, which is qualified with its package path so that
+// the types with the same name in different packages do not collide, i.e. github.com.user.models.Node
+func (s *openAPISchemas) componentName(tip reflect.Type) string {
+	if name, ok := s.names[tip]; ok {
+		return name
+	}
+
+	// The names can only have letters, digits, dots, dashes and underscores
+	base := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		case r == '/':
+			return '.'
+		}
+		return '_'
+	}, tip.PkgPath()+"."+tip.Name())
+
+	name := base
+	for i := 2; s.types[name] != nil; i++ {
+		name = base + "_" + strconv.Itoa(i)
+	}
+
+	s.names[tip] = name
+	s.types[name] = tip
+	return name
+}
+
+// parameters creates a parameter for each field of the flat struct in the given special field, nameTag is
+// the struct tag that overrides the parameter name if set
+func (s *openAPISchemas) parameters(handlerType reflect.Type, fieldName, in, nameTag string) []*OpenAPIParameter {
+	field, _ := handlerType.FieldByName(fieldName)
+	tip := field.Type
+
+	params := make([]*OpenAPIParameter, 0, tip.NumField())
+	for i := 0; i < tip.NumField(); i++ {
+		f := tip.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup(nameTag); ok && nameTag != "" {
+			name = tag
+		}
+
+		schema := s.schema(f.Type, "")
+		required := applyValidTag(schema, f.Tag.Get("valid"))
+		param := &OpenAPIParameter{
+			Name:     name,
+			In:       in,
+			Required: required,
+			Schema:   schema,
+		}
+
+		// Comma separated values are not exploded to repeated keys
+		if sep := f.Tag.Get(TagSeparator); isMultiValue(f.Type) && sep == "," && in == "query" {
+			explode := false
+			param.Style = "form"
+			param.Explode = &explode
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// schema derives the schema of a Go type, nameTag is the struct tag that overrides the property names if set
+func (s *openAPISchemas) schema(tip reflect.Type, nameTag string) *OpenAPISchema {
+	for tip.Kind() == reflect.Ptr {
+		if tip == uploadedFileType {
+			return &OpenAPISchema{Type: "string", Format: "binary"}
+		}
+		tip = tip.Elem()
+	}
+
+	if tip == timeType {
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	} else if tip == durationType {
+		return &OpenAPISchema{Type: "string"}
+	}
+
+	switch tip.Kind() {
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &OpenAPISchema{Type: "integer", Minimum: &zero}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if tip.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: s.schema(tip.Elem(), nameTag)}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: s.schema(tip.Elem(), nameTag)}
+	case reflect.Struct:
+		return s.object(tip, nameTag)
+	}
+
+	// Interfaces and others can be anything
+	return &OpenAPISchema{}
+}
+
+// object derives the schema of a struct, which is a reference to the components if the struct contains itself
+func (s *openAPISchemas) object(tip reflect.Type, nameTag string) *OpenAPISchema {
+	if s.visiting[tip] {
+		s.recursive[tip] = true
+		return &OpenAPISchema{Ref: "#/components/schemas/" + s.componentName(tip)}
+	}
+	s.visiting[tip] = true
+	defer delete(s.visiting, tip)
+
+	schema := &OpenAPISchema{
+		Type:       "object",
+		Properties: make(map[string]*OpenAPISchema),
+	}
+	s.properties(schema, tip, nameTag)
+
+	if s.recursive[tip] {
+		name := s.componentName(tip)
+		s.components[name] = schema
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	return schema
+}
+
+// properties adds the fields of the struct to the properties of the schema. The fields of the embedded structs are
+// inlined after the others like encoding/json does, so the fields of the outer struct take precedence.
+func (s *openAPISchemas) properties(schema *OpenAPISchema, tip reflect.Type, nameTag string) {
+	var embedded []reflect.Type
+	for i := 0; i < tip.NumField(); i++ {
+		f := tip.Field(i)
+
+		name, named := f.Name, false
+		if tag, ok := f.Tag.Lookup(nameTag); ok && nameTag != "" {
+			tag = strings.Split(tag, ",")[0]
+			if tag == "-" {
+				continue
+			} else if tag != "" {
+				name, named = tag, true
+			}
+		}
+
+		// The embedded structs are inlined even if they are unexported, unless they are named with the tag
+		if embeddedType := f.Type; f.Anonymous && !named {
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct && embeddedType != timeType {
+				embedded = append(embedded, embeddedType)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		property := s.schema(f.Type, nameTag)
+		if applyValidTag(property, f.Tag.Get("valid")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+
+	for _, embeddedType := range embedded {
+		// A struct that embeds itself has nothing more to inline
+		if s.inlining[embeddedType] || embeddedType == tip {
+			continue
+		}
+		s.inlining[embeddedType] = true
+
+		inlined := &OpenAPISchema{Properties: make(map[string]*OpenAPISchema)}
+		s.properties(inlined, embeddedType, nameTag)
+		delete(s.inlining, embeddedType)
+
+		for name, property := range inlined.Properties {
+			if _, ok := schema.Properties[name]; !ok {
+				schema.Properties[name] = property
+			}
+		}
+		for _, name := range inlined.Required {
+			if _, ok := schema.Properties[name]; ok && schema.Properties[name] == inlined.Properties[name] {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+}
+
+// applyValidTag maps the govalidator validators in the tag to schema constraints, and returns whether the field is
+// required
+func applyValidTag(schema *OpenAPISchema, tag string) bool {
+	required := false
+	for _, option := range strings.Split(tag, ",") {
+		// Strip the custom error message
+		option = strings.TrimSpace(strings.Split(option, "~")[0])
+		name, args := option, []string{}
+		if idx := strings.Index(option, "("); idx != -1 && strings.HasSuffix(option, ")") {
+			name = option[:idx]
+			args = strings.Split(option[idx+1:len(option)-1], "|")
+		}
+
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url", "requrl", "requri":
+			schema.Format = "uri"
+		case "uuid", "uuidv3", "uuidv4", "uuidv5":
+			schema.Format = "uuid"
+		case "ipv4":
+			schema.Format = "ipv4"
+		case "ipv6":
+			schema.Format = "ipv6"
+		case "rfc3339":
+			schema.Format = "date-time"
+		case "alpha":
+			schema.Pattern = "^[a-zA-Z]+$"
+		case "alphanum":
+			schema.Pattern = "^[a-zA-Z0-9]+$"
+		case "numeric":
+			schema.Pattern = "^[0-9]+$"
+		case "matches":
+			if len(args) == 1 {
+				schema.Pattern = args[0]
+			}
+		case "in":
+			schema.Enum = args
+		case "range":
+			if len(args) == 2 {
+				schema.Minimum = parseFloatOrNil(args[0])
+				schema.Maximum = parseFloatOrNil(args[1])
+			}
+		case "length", "runelength", "stringlength":
+			if len(args) == 2 {
+				schema.MinLength = parseIntOrNil(args[0])
+				schema.MaxLength = parseIntOrNil(args[1])
+			}
+		}
+	}
+	return required
+}
+
+func parseFloatOrNil(s string) *float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+func parseIntOrNil(s string) *int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &i
+}
+
+func hasUploadedFile(tip reflect.Type) bool {
+	for i := 0; i < tip.NumField(); i++ {
+		if isFileField(tip.Field(i).Type) {
+			return true
+		}
+	}
+	return false
+}
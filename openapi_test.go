@@ -0,0 +1,227 @@
+package gongular
+
+import (
+	"encoding/json"
+	htmltemplate "html/template"
+	"net/http"
+	"reflect"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type openAPIUserHandler struct {
+	Param struct {
+		UserID int
+	}
+	Query struct {
+		Fields string `q:"fields" valid:"in(name|email)"`
+		Limit  int    `valid:"range(1|100)"`
+	}
+	Body struct {
+		Name  string `json:"name" valid:"required,length(2|32)"`
+		Email string `json:"email" valid:"email"`
+		Tags  []string
+	}
+}
+
+func (o *openAPIUserHandler) Handle(c *Context) error {
+	return nil
+}
+
+type openAPIFileHandler struct {
+	Param struct {
+		Path string
+	}
+	Form struct {
+		File *UploadedFile
+	}
+}
+
+func (o *openAPIFileHandler) Handle(c *Context) error {
+	return nil
+}
+
+func TestEngine_Routes(t *testing.T) {
+	e := newEngineTest()
+	g := e.GetRouter().Group("/api/user/:UserID", &middlewareFailIfUserId5{})
+	g.GET("/name", &simpleHandler{})
+
+	routes := e.Routes()
+	require.Len(t, routes, 1)
+	assert.Equal(t, http.MethodGet, routes[0].Method)
+	assert.Equal(t, "/api/user/:UserID/name", routes[0].Path)
+	require.Len(t, routes[0].Handlers, 2)
+	assert.Equal(t, "middlewareFailIfUserId5", routes[0].Handlers[0].Name())
+	assert.Equal(t, "simpleHandler", routes[0].Handlers[1].Name())
+}
+
+func TestEngine_OpenAPI(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().PUT("/user/:UserID", &openAPIUserHandler{})
+	e.GetRouter().POST("/files/*Path", &openAPIFileHandler{})
+	e.GetRouter().GET("/", &simpleHandler{})
+
+	doc := e.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.0"})
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+
+	op := doc.Paths["/user/{UserID}"]["put"]
+	require.NotNil(t, op)
+	require.Len(t, op.Parameters, 3)
+
+	assert.Equal(t, "UserID", op.Parameters[0].Name)
+	assert.Equal(t, "path", op.Parameters[0].In)
+	assert.True(t, op.Parameters[0].Required)
+	assert.Equal(t, "integer", op.Parameters[0].Schema.Type)
+
+	assert.Equal(t, "fields", op.Parameters[1].Name)
+	assert.Equal(t, "query", op.Parameters[1].In)
+	assert.Equal(t, []string{"name", "email"}, op.Parameters[1].Schema.Enum)
+
+	assert.Equal(t, 1.0, *op.Parameters[2].Schema.Minimum)
+	assert.Equal(t, 100.0, *op.Parameters[2].Schema.Maximum)
+
+	body := op.RequestBody.Content["application/json"].Schema
+	assert.Equal(t, "object", body.Type)
+	assert.Equal(t, []string{"name"}, body.Required)
+	assert.Equal(t, 2, *body.Properties["name"].MinLength)
+	assert.Equal(t, 32, *body.Properties["name"].MaxLength)
+	assert.Equal(t, "email", body.Properties["email"].Format)
+	assert.Equal(t, "array", body.Properties["Tags"].Type)
+	assert.Contains(t, op.Responses, "400")
+
+	fileOp := doc.Paths["/files/{Path}"]["post"]
+	require.NotNil(t, fileOp)
+	form := fileOp.RequestBody.Content["multipart/form-data"].Schema
+	assert.Equal(t, "binary", form.Properties["File"].Format)
+
+	rootOp := doc.Paths["/"]["get"]
+	require.NotNil(t, rootOp)
+	assert.Empty(t, rootOp.Parameters)
+	assert.NotContains(t, rootOp.Responses, "400")
+}
+
+func TestEngine_OpenAPISerialization(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().PUT("/user/:UserID", &openAPIUserHandler{})
+	doc := e.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.0"})
+
+	b, err := doc.JSON()
+	require.NoError(t, err)
+	var fromJSON map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &fromJSON))
+	assert.Equal(t, "3.1.0", fromJSON["openapi"])
+
+	y, err := doc.YAML()
+	require.NoError(t, err)
+	var fromYAML map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(y, &fromYAML))
+	assert.Equal(t, "3.1.0", fromYAML["openapi"])
+	assert.Contains(t, fromYAML["paths"], "/user/{UserID}")
+}
+
+func TestEngine_ServeOpenAPI(t *testing.T) {
+	e := newEngineTest()
+	e.ServeOpenAPI("/openapi.json", OpenAPIInfo{Title: "Test", Version: "1.0"})
+	e.GetRouter().GET("/", &simpleHandler{})
+
+	resp, content := get(t, e, "/openapi.json")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var doc OpenAPIDocument
+	require.NoError(t, json.Unmarshal([]byte(content), &doc))
+	assert.Contains(t, doc.Paths, "/")
+}
+
+type openAPINode struct {
+	Name     string        `json:"name"`
+	Parent   *openAPINode  `json:"parent"`
+	Children []openAPINode `json:"children"`
+}
+
+type openAPITreeHandler struct {
+	Body struct {
+		Root openAPINode `json:"root"`
+	}
+}
+
+func (o *openAPITreeHandler) Handle(c *Context) error {
+	return nil
+}
+
+func TestEngine_OpenAPIRecursive(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/tree", &openAPITreeHandler{})
+	e.GetRouter().PUT("/tree", &openAPITreeHandler{})
+	doc := e.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.0"})
+
+	ref := "#/components/schemas/github.com.mustafaakin.gongular.openAPINode"
+	for _, method := range []string{"post", "put"} {
+		body := doc.Paths["/tree"][method].RequestBody.Content["application/json"].Schema
+		assert.Equal(t, ref, body.Properties["root"].Ref)
+	}
+
+	require.NotNil(t, doc.Components)
+	node := doc.Components.Schemas["github.com.mustafaakin.gongular.openAPINode"]
+	require.NotNil(t, node)
+	assert.Equal(t, "string", node.Properties["name"].Type)
+	assert.Equal(t, ref, node.Properties["parent"].Ref)
+	assert.Equal(t, ref, node.Properties["children"].Items.Ref)
+
+	b, err := doc.JSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"$ref": "`+ref+`"`)
+}
+
+func TestEngine_OpenAPIComponentNames(t *testing.T) {
+	s := newOpenAPISchemas()
+	text := s.componentName(reflect.TypeOf(texttemplate.Template{}))
+	html := s.componentName(reflect.TypeOf(htmltemplate.Template{}))
+
+	assert.Equal(t, "text.template.Template", text)
+	assert.Equal(t, "html.template.Template", html)
+	assert.Equal(t, text, s.componentName(reflect.TypeOf(texttemplate.Template{})))
+}
+
+type openAPIBase struct {
+	ID      int    `json:"id" valid:"required"`
+	Created string `json:"created"`
+}
+
+type OpenAPIAudit struct {
+	By string `json:"by"`
+}
+
+type openAPIEmbeddingHandler struct {
+	Body struct {
+		openAPIBase
+		*OpenAPIAudit
+		Named   openAPIBase `json:"named"`
+		Created int         `json:"created"`
+		Name    string      `json:"name"`
+	}
+}
+
+func (o *openAPIEmbeddingHandler) Handle(c *Context) error {
+	return nil
+}
+
+func TestEngine_OpenAPIEmbedded(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/", &openAPIEmbeddingHandler{})
+	doc := e.OpenAPI(OpenAPIInfo{Title: "Test", Version: "1.0"})
+
+	body := doc.Paths["/"]["post"].RequestBody.Content["application/json"].Schema
+	assert.Equal(t, "integer", body.Properties["id"].Type)
+	assert.Equal(t, "string", body.Properties["by"].Type)
+	assert.Equal(t, []string{"id"}, body.Required)
+	assert.NotContains(t, body.Properties, "openAPIBase")
+	assert.NotContains(t, body.Properties, "OpenAPIAudit")
+
+	// The fields of the outer struct take precedence, and a struct named with the tag is not inlined
+	assert.Equal(t, "integer", body.Properties["created"].Type)
+	assert.Equal(t, "object", body.Properties["named"].Type)
+}
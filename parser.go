@@ -1,7 +1,6 @@
 package gongular
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -25,6 +24,12 @@ const (
 	// PlaceForm is used in ValidationError to indicate the error is in
 	// submitted form
 	PlaceForm = "Form Value"
+	// PlaceHeader is used in ValidationError to indicate the error is in
+	// request headers
+	PlaceHeader = "Header"
+	// PlaceCookie is used in ValidationError to indicate the error is in
+	// request cookies
+	PlaceCookie = "Cookie"
 )
 
 const (
@@ -36,18 +41,54 @@ const (
 	FieldForm = "Form"
 	// FieldQuery defines the struct field name for looking up QUery Parameters
 	FieldQuery = "Query"
+	// FieldHeader defines the struct field name for looking up request headers
+	FieldHeader = "Header"
+	// FieldCookie defines the struct field name for looking up request cookies
+	FieldCookie = "Cookie"
+	// FieldResponse defines the struct field name for the declared response of the handler
+	FieldResponse = "Response"
 )
 
+// isSpecialField returns whether the field with the given name is bound from the request or
+// used for the response, rather than injected
+func isSpecialField(name string) bool {
+	switch name {
+	case FieldParameter, FieldBody, FieldForm, FieldQuery, FieldHeader, FieldCookie, FieldResponse:
+		return true
+	}
+	return false
+}
+
 const (
 	// TagInject The field name that is used to lookup injections in the handlers
 	TagInject = "inject"
 	// TagQuery is the field tag to define a query parameter's key
 	TagQuery = "q"
+	// TagHeader is the field tag to define a header's name
+	TagHeader = "header"
+	// TagCookie is the field tag to define a cookie's name
+	TagCookie = "cookie"
+	// TagSeparator is the field tag to split the values of a slice or array field by the given separator,
+	// in addition to the repeated keys
+	TagSeparator = "sep"
+	// TagLayout is the field tag to define the layout of a time.Time field, RFC3339 by default
+	TagLayout = "layout"
+	// TagDefault is the field tag to define the value of a field when it is missing in the request
+	TagDefault = "default"
+	// TagStatus is the field tag to define the status code of the Response field
+	TagStatus = "status"
+	// TagMaxSize is the field tag to define the maximum size of an uploaded file in bytes
+	TagMaxSize = "maxsize"
+	// TagMimeType is the field tag to define the allowed content types of an uploaded file, separated by commas
+	TagMimeType = "mime"
+	// TagExtension is the field tag to define the allowed extensions of an uploaded file, separated by commas
+	TagExtension = "ext"
+	// TagSniff is the field tag to define the allowed content types of an uploaded file, detected from its content
+	TagSniff = "sniff"
 )
 
-var (
-	errUnassignable = errors.New("value is not assignable to this type")
-)
+// defaultMaxMemory is the same as the one used by http.Request.FormValue
+const defaultMaxMemory = 32 << 20
 
 func parseInt(kind reflect.Kind, s string, place string, field reflect.StructField, val *reflect.Value) error {
 	i, err := strconv.ParseInt(s, 10, 64)
@@ -134,22 +175,51 @@ func parseBool(s string, place string, field reflect.StructField, val *reflect.V
 	return nil
 }
 
-func parseSimpleParam(s string, place string, field reflect.StructField, val *reflect.Value) error {
-	kind := field.Type.Kind()
-	var err error
+// simpleParser returns the parseFunc for the strings, integers, floats and booleans, or nil for the other kinds
+func simpleParser(kind reflect.Kind) parseFunc {
 	switch kind {
 	case reflect.String:
-		val.SetString(s)
+		return parseString
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		err = parseInt(kind, s, place, field, val)
+		return func(s string, place string, field reflect.StructField, val *reflect.Value) error {
+			return parseInt(kind, s, place, field, val)
+		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		err = parseUint(kind, s, place, field, val)
+		return func(s string, place string, field reflect.StructField, val *reflect.Value) error {
+			return parseUint(kind, s, place, field, val)
+		}
 	case reflect.Float32, reflect.Float64:
-		err = parseFloat(kind, s, place, field, val)
+		return func(s string, place string, field reflect.StructField, val *reflect.Value) error {
+			return parseFloat(kind, s, place, field, val)
+		}
 	case reflect.Bool:
-		err = parseBool(s, place, field, val)
+		return parseBool
+	}
+	return nil
+}
+
+func parseString(s string, place string, field reflect.StructField, val *reflect.Value) error {
+	val.SetString(s)
+	return nil
+}
+
+// isMultiValue returns whether the field is bound from multiple values, i.e. repeated keys
+func isMultiValue(tip reflect.Type) bool {
+	kind := tip.Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// splitValues splits each of the values by the separator in the sep tag of the field, if it is not empty
+func splitValues(values []string, sep string) []string {
+	if sep == "" {
+		return values
+	}
+
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, strings.Split(v, sep)...)
 	}
-	return err
+	return result
 }
 
 func validateStruct(obj reflect.Value, place string) error {
@@ -164,32 +234,75 @@ func validateStruct(obj reflect.Value, place string) error {
 	return nil
 }
 
-func (c *Context) parseParams(obj reflect.Value) error {
-	param := obj.FieldByName(FieldParameter)
-	paramType := param.Type()
+// validateBound validates the bound struct, and also reports the required fields that are missing in the request
+func validateBound(obj reflect.Value, place string, missing map[string]string) error {
+	err := validateStruct(obj, place)
+	if len(missing) == 0 {
+		return err
+	}
 
-	numFields := paramType.NumField()
-	for i := 0; i < numFields; i++ {
-		field := paramType.Field(i)
+	if verr, ok := err.(ValidationError); ok {
+		for k, v := range verr.Fields {
+			if _, ok := missing[k]; !ok {
+				missing[k] = v
+			}
+		}
+	}
 
-		s := c.Params().ByName(field.Name)
-		val := param.Field(i)
-		err := parseSimpleParam(s, PlaceParameter, field, &val)
-		if err != nil {
-			return err
+	return ValidationError{
+		Place:  place,
+		Fields: missing,
+	}
+}
+
+// isRequired returns whether the field has the required validator
+func isRequired(field reflect.StructField) bool {
+	return hasValidator(field, "required")
+}
+
+// isOptional returns whether the field has the optional validator
+func isOptional(field reflect.StructField) bool {
+	return hasValidator(field, "optional")
+}
+
+// hasValidator returns whether the valid tag of the field has the given validator
+func hasValidator(field reflect.StructField, name string) bool {
+	for _, option := range strings.Split(field.Tag.Get("valid"), ",") {
+		if strings.TrimSpace(strings.Split(option, "~")[0]) == name {
+			return true
 		}
 	}
+	return false
+}
 
-	return validateStruct(param, PlaceParameter)
+func (c *Context) parseParams(obj reflect.Value, plan *bindingPlan) error {
+	return plan.bind(obj, func(key string) []string {
+		for _, p := range c.Params() {
+			if p.Key == key {
+				return []string{p.Value}
+			}
+		}
+		return nil
+	})
 }
 
-func (c *Context) parseBody(handlerObject reflect.Value) error {
+func (c *Context) parseBody(handlerObject reflect.Value, b *binder) error {
 	// TODO: Cache body if possible?
 	body := handlerObject.FieldByName(FieldBody)
-	b := body.Addr().Interface()
 
-	err := json.NewDecoder(c.Request().Body).Decode(b)
+	decode, err := b.decoder(c.Request().Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+
+	err = decode(c.Request().Body, body.Addr().Interface())
 	if err != nil {
+		var tooLarge BodyTooLargeError
+		if errors.As(err, &tooLarge) {
+			return tooLarge
+		} else if perr, ok := err.(ParseError); ok {
+			return perr
+		}
 		return ParseError{
 			Place:  PlaceBody,
 			Reason: err.Error(),
@@ -199,156 +312,125 @@ func (c *Context) parseBody(handlerObject reflect.Value) error {
 	return validateStruct(body, PlaceBody)
 }
 
-func (c *Context) parseQuery(obj reflect.Value) error {
-	query := obj.FieldByName(FieldQuery)
-	queryType := query.Type()
-
-	numFields := queryType.NumField()
+func (c *Context) parseQuery(obj reflect.Value, plan *bindingPlan) error {
 	queryValues := c.Request().URL.Query()
-	for i := 0; i < numFields; i++ {
-		field := queryType.Field(i)
 
-		var s string
-		tag, ok := field.Tag.Lookup(TagQuery)
-		if ok {
-			s = queryValues.Get(tag)
-		} else {
-			s = queryValues.Get(field.Name)
-		}
+	return plan.bind(obj, func(key string) []string {
+		return queryValues[key]
+	})
+}
 
-		if s == "" {
-			// Do not fail right now, it is the job of validator
-			continue
-		}
+func (c *Context) parseHeaders(obj reflect.Value, plan *bindingPlan) error {
+	header := c.Request().Header
+
+	// The keys are canonicalized when the plan is compiled
+	return plan.bind(obj, func(key string) []string {
+		return header[key]
+	})
+}
 
-		val := query.Field(i)
+func (c *Context) parseCookies(obj reflect.Value, plan *bindingPlan) error {
+	cookies := c.Request().Cookies()
 
-		err := parseSimpleParam(s, PlaceQuery, field, &val)
-		if err != nil {
-			return err
+	return plan.bind(obj, func(key string) []string {
+		for _, ck := range cookies {
+			if ck.Name == key {
+				return []string{ck.Value}
+			}
 		}
-	}
-	return validateStruct(query, PlaceQuery)
+		return nil
+	})
 }
 
-func (c *Context) parseForm(obj reflect.Value) error {
-	form := obj.FieldByName(FieldForm)
-	formType := form.Type()
+func (c *Context) parseForm(obj reflect.Value, plan *bindingPlan, maxMemory int64) error {
+	form := obj.Field(plan.index)
 
-	numFields := formType.NumField()
+	// The body is left to the handler as a multipart.Reader in the streaming mode
+	if plan.stream {
+		return c.streamForm(form)
+	}
 
-	for i := 0; i < numFields; i++ {
-		field := formType.Field(i)
-		// If it is a file, parse the form
-		if field.Type == reflect.TypeOf(&UploadedFile{}) {
-			file, header, err := c.Request().FormFile(field.Name)
+	// ParseMultipartForm hides the errors of ParseForm for the url encoded forms, so it is called first
+	err := c.Request().ParseForm()
+	if err == nil {
+		err = c.Request().ParseMultipartForm(maxMemory)
+	}
+	if err != nil && err != http.ErrNotMultipart {
+		var tooLarge BodyTooLargeError
+		if errors.As(err, &tooLarge) {
+			return tooLarge
+		}
+
+		return ParseError{
+			Place:  PlaceForm,
+			Reason: err.Error(),
+		}
+	}
 
-			// TODO: Make it optional??
-			if err == http.ErrMissingFile {
+	missing := make(map[string]string)
+	for i := range plan.fields {
+		fb := &plan.fields[i]
+		val := form.Field(fb.index)
+
+		// If it is a file, parse the form
+		if fb.file {
+			ok, invalid, err := c.parseFiles(fb.field, val)
+			if err != nil {
+				return err
+			} else if invalid != "" {
+				missing[fb.field.Name] = invalid
+			} else if !ok && !fb.optional {
 				return ParseError{
 					Place:     PlaceForm,
-					FieldName: field.Name,
+					FieldName: fb.field.Name,
 					Reason:    "Was expecting a file, but could not found in the request.",
 				}
-			} else if err != nil {
-				// It should be an internal error, therefore we do not wrap with ParseError
-				return err
-			}
-
-			// Pack them to a single structure
-			uploadedFile := &UploadedFile{
-				File:   file,
-				Header: header,
 			}
-
-			form.Field(i).Set(reflect.ValueOf(uploadedFile))
 		} else {
-			s := c.Request().FormValue(field.Name)
-			val := form.Field(i)
-			err := parseSimpleParam(s, PlaceForm, field, &val)
+			ok, err := fb.bind(c.formValues(fb.key), PlaceForm, val)
 			if err != nil {
 				return err
+			} else if !ok && fb.required {
+				missing[fb.field.Name] = "missing required value"
 			}
 		}
 	}
-	return validateStruct(form, PlaceForm)
+	return validateBound(form, PlaceForm, missing)
 }
 
-func (c *Context) parseInjections(obj reflect.Value, injector *injector) error {
-	numFields := obj.Type().NumField()
-
-	for i := 0; i < numFields; i++ {
-		field := obj.Type().Field(i)
-		tip := field.Type
-		name := field.Name
-
-		// We can skip the field if it is a special one
-		if name == FieldBody || name == FieldParameter || name == FieldQuery || name == FieldForm {
-			continue
-		}
-
-		if !obj.Field(i).CanSet() {
-			// It is an un-exported one
-			continue
-		}
-
-		var key string
-		tag, ok := field.Tag.Lookup(TagInject)
-		if !ok {
-			key = "default"
-		} else {
-			key = tag
+// streamForm sets the multipart.Reader field of the form without reading the body
+func (c *Context) streamForm(form reflect.Value) error {
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return ParseError{
+			Place:  PlaceForm,
+			Reason: err.Error(),
 		}
-		fieldObj := obj.Field(i)
+	}
 
-		err := c.setInjectionForField(tip, key, injector, fieldObj)
-		if err != nil {
-			return err
+	for i := 0; i < form.NumField(); i++ {
+		if form.Type().Field(i).Type == multipartReaderType {
+			form.Field(i).Set(reflect.ValueOf(reader))
 		}
 	}
 	return nil
 }
 
-func (c *Context) setInjectionForField(tip reflect.Type, key string, injector *injector, fieldObj reflect.Value) error {
-	cachedVal, cachedOk := c.getCachedInjection(tip, key)
-	val, directOk := injector.GetDirectValue(tip, key)
-	fn, customOk := injector.GetCustomValue(tip, key)
-	uval, uvalOK := injector.GetUnsafeValue(key)
-
-	if uvalOK {
-		if !uval.Type().AssignableTo(tip) {
-			return InjectionError{
-				Key:             key,
-				Tip:             tip,
-				UnderlyingError: errUnassignable,
-			}
-		}
-		fieldObj.Set(uval)
-		return nil
-	} else if cachedOk {
-		fieldObj.Set(reflect.ValueOf(cachedVal))
-		return nil
-	} else if directOk {
-		fieldObj.Set(reflect.ValueOf(val))
-		return nil
-	} else if customOk {
-		val, err := fn(c)
-		if err != nil {
-			return InjectionError{
-				Key:             key,
-				Tip:             tip,
-				UnderlyingError: err,
-			}
-		}
-		fieldObj.Set(reflect.ValueOf(val))
-		c.putCachedInjection(tip, key, val)
-		return nil
+// formValues returns all the values of the form key, parsing the form the same way FormValue does
+func (c *Context) formValues(key string) []string {
+	r := c.Request()
+	if r.Form == nil {
+		r.ParseMultipartForm(defaultMaxMemory)
 	}
+	return r.Form[key]
+}
 
-	// We should not be here if the programmatic check is done correctly, but placed it here anyways
-	return InjectionError{
-		Key:             key,
-		Tip:             tip,
-		UnderlyingError: ErrNoSuchDependency,
+func (c *Context) parseInjections(obj reflect.Value, injections []resolvedInjection) error {
+	for _, injection := range injections {
+		err := injection.set(c, obj)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
@@ -236,3 +236,170 @@ func TestBodyHandler(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.Code)
 	assert.Equal(t, `"mustafa:26:4:5:9:50"`, content)
 }
+
+type headerCookieHandler struct {
+	Header struct {
+		RequestID string `header:"X-Request-ID" valid:"alphanum"`
+		Retries   int    `header:"X-Retries"`
+	}
+	Cookie struct {
+		Session string `cookie:"session"`
+		Dark    bool
+	}
+}
+
+func (h *headerCookieHandler) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%s:%d:%s:%t", h.Header.RequestID, h.Header.Retries, h.Cookie.Session, h.Cookie.Dark))
+	return nil
+}
+
+func TestHeaderCookieHandler(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &headerCookieHandler{})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "9b2b3d4e6a34")
+	req.Header.Set("x-retries", "3")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	req.AddCookie(&http.Cookie{Name: "Dark", Value: "true"})
+
+	resp, content := serve(t, e, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"9b2b3d4e6a34:3:abc:true"`, content)
+
+	// All of them are optional
+	resp2, content2 := get(t, e, "/")
+	assert.Equal(t, http.StatusOK, resp2.Code)
+	assert.Equal(t, `":0::false"`, content2)
+}
+
+type multiValueQueryHandler struct {
+	Query struct {
+		Tags  []string `q:"tag"`
+		IDs   []int    `q:"id" sep:","`
+		Point [2]float64
+	}
+}
+
+func (m *multiValueQueryHandler) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%v:%v:%v", m.Query.Tags, m.Query.IDs, m.Query.Point))
+	return nil
+}
+
+func TestMultiValueQueryHandler(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &multiValueQueryHandler{})
+
+	resp, content := get(t, e, "/?tag=a&tag=b&id=1,2&id=3&Point=1.5&Point=2")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"[a b]:[1 2 3]:[1.5 2]"`, content)
+
+	resp2, content2 := get(t, e, "/")
+	assert.Equal(t, http.StatusOK, resp2.Code)
+	assert.Equal(t, `"[]:[]:[0 0]"`, content2)
+}
+
+type multiValueFormHandler struct {
+	Form struct {
+		Choices []int
+	}
+	Header struct {
+		Accept []string `sep:","`
+	}
+}
+
+func (m *multiValueFormHandler) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%v:%v", m.Form.Choices, m.Header.Accept))
+	return nil
+}
+
+func TestMultiValueFormHandler(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/", &multiValueFormHandler{})
+
+	values := url.Values{"Choices": []string{"3", "5", "8"}}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(values.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept", "text/html,application/json")
+	req.Header.Add("Accept", "text/plain")
+
+	resp, content := serve(t, e, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"[3 5 8]:[text/html application/json text/plain]"`, content)
+}
+
+type optionalQueryHandler struct {
+	Query struct {
+		Limit  *int
+		Active *bool
+		Page   int      `default:"1"`
+		Sort   string   `default:"name"`
+		Tags   []string `default:"a,b" sep:","`
+	}
+}
+
+func (o *optionalQueryHandler) Handle(c *Context) error {
+	limit := "nil"
+	if o.Query.Limit != nil {
+		limit = fmt.Sprint(*o.Query.Limit)
+	}
+	active := "nil"
+	if o.Query.Active != nil {
+		active = fmt.Sprint(*o.Query.Active)
+	}
+	c.SetBody(fmt.Sprintf("%s:%s:%d:%s:%v", limit, active, o.Query.Page, o.Query.Sort, o.Query.Tags))
+	return nil
+}
+
+func TestOptionalQueryHandler(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &optionalQueryHandler{})
+
+	resp, content := get(t, e, "/")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"nil:nil:1:name:[a b]"`, content)
+
+	resp2, content2 := get(t, e, "/?Limit=0&Active=false&Page=3&Sort=age&Tags=c")
+	assert.Equal(t, http.StatusOK, resp2.Code)
+	assert.Equal(t, `"0:false:3:age:[c]"`, content2)
+}
+
+type requiredFieldsHandler struct {
+	Header struct {
+		Token string `header:"X-Token" valid:"required"`
+	}
+	Form struct {
+		Count *int `valid:"required"`
+		Name  string
+	}
+}
+
+func (r *requiredFieldsHandler) Handle(c *Context) error {
+	c.SetBody(fmt.Sprintf("%s:%d", r.Header.Token, *r.Form.Count))
+	return nil
+}
+
+func TestRequiredFieldsHandler(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/", &requiredFieldsHandler{})
+
+	resp, content := postForm(t, e, "/", url.Values{"Count": []string{"0"}})
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, content, `"Token": "missing required value"`)
+	assert.Contains(t, content, PlaceHeader)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{"Name": []string{"x"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Token", "secret")
+	resp2, content2 := serve(t, e, req)
+	assert.Equal(t, http.StatusBadRequest, resp2.Code)
+	assert.Contains(t, content2, `"Count": "missing required value"`)
+	assert.Contains(t, content2, PlaceForm)
+
+	req3 := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{"Count": []string{"0"}}.Encode()))
+	req3.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req3.Header.Set("X-Token", "secret")
+	resp3, content3 := serve(t, e, req3)
+	assert.Equal(t, http.StatusOK, resp3.Code)
+	assert.Equal(t, `"secret:0"`, content3)
+}
@@ -0,0 +1,82 @@
+package gongular
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// ResponseDescriber can be implemented by handlers to declare the responses other than the success response, i.e.
+// the error responses, so that they can be introspected from the registered routes
+type ResponseDescriber interface {
+	Responses() []ResponseDescription
+}
+
+// ResponseDescription describes a possible response of a handler. Body is a sample value whose type is the
+// type of the response body, nil if the response has no body.
+type ResponseDescription struct {
+	Status      int
+	Description string
+	Body        interface{}
+}
+
+// checkResponse analyzes the Response field and the declared responses of the handler
+func (hc *handlerContext) checkResponse(handlerElem reflect.Type, handler interface{}) error {
+	response, responseOk := handlerElem.FieldByName(FieldResponse)
+	if responseOk {
+		hc.responseStatus = http.StatusOK
+		if tag, ok := response.Tag.Lookup(TagStatus); ok {
+			status, err := strconv.Atoi(tag)
+			if err != nil || status < 100 || status > 999 {
+				return fmt.Errorf("Response field has an invalid status tag: %q", tag)
+			}
+			hc.responseStatus = status
+		}
+	}
+	hc.response = responseOk
+
+	if describer, ok := handler.(ResponseDescriber); ok {
+		hc.responses = describer.Responses()
+	}
+	return nil
+}
+
+// setResponse writes the Response field of the handler as the body with its status, if no handler set a body itself.
+// It replaces the Response field of a previous handler, so the last one in the chain is written as it is described.
+func (hc *handlerContext) setResponse(c *Context, objElem reflect.Value) {
+	if c.body != nil && !c.responseBody {
+		return
+	}
+
+	c.body = objElem.FieldByName(FieldResponse).Interface()
+	c.responseBody = true
+	if c.status == 0 || c.responseStatus {
+		c.status = hc.responseStatus
+		c.responseStatus = true
+	}
+}
+
+// describeResponses returns the declared success response, which is the last Response field in the chain, and the
+// declared responses of all handlers in the route
+func (rt *route) describeResponses() []ResponseDescription {
+	var success *ResponseDescription
+	var others []ResponseDescription
+
+	for _, hc := range rt.handlers {
+		if hc.response {
+			field, _ := hc.tip.FieldByName(FieldResponse)
+			success = &ResponseDescription{
+				Status:      hc.responseStatus,
+				Description: http.StatusText(hc.responseStatus),
+				Body:        reflect.Zero(field.Type).Interface(),
+			}
+		}
+		others = append(others, hc.responses...)
+	}
+
+	if success == nil {
+		return others
+	}
+	return append([]ResponseDescription{*success}, others...)
+}
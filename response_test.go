@@ -0,0 +1,134 @@
+package gongular
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type createdUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type responseHandler struct {
+	Body struct {
+		Name string
+	}
+	Response createdUser `status:"201"`
+}
+
+func (r *responseHandler) Handle(c *Context) error {
+	if r.Body.Name == "taken" {
+		c.Fail(http.StatusConflict, "Name is taken")
+		return nil
+	}
+
+	r.Response = createdUser{ID: 7, Name: r.Body.Name}
+	return nil
+}
+
+func (r *responseHandler) Responses() []ResponseDescription {
+	return []ResponseDescription{
+		{Status: http.StatusConflict, Description: "The name is already taken", Body: ""},
+	}
+}
+
+func TestResponseField(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/user", &responseHandler{})
+
+	resp, content := post(t, e, "/user", map[string]string{"Name": "mustafa"})
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.JSONEq(t, `{"id": 7, "name": "mustafa"}`, content)
+
+	resp2, content2 := post(t, e, "/user", map[string]string{"Name": "taken"})
+	assert.Equal(t, http.StatusConflict, resp2.Code)
+	assert.Equal(t, `"Name is taken"`, content2)
+}
+
+type responseDefaultStatusHandler struct {
+	Response []string
+}
+
+func (r *responseDefaultStatusHandler) Handle(c *Context) error {
+	r.Response = []string{"a", "b"}
+	return nil
+}
+
+func TestResponseFieldDefaultStatus(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/", &responseDefaultStatusHandler{})
+
+	resp, content := get(t, e, "/")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `["a", "b"]`, content)
+}
+
+func TestResponseIntrospection(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/user", &responseHandler{})
+
+	routes := e.Routes()
+	require.Len(t, routes, 1)
+	require.Len(t, routes[0].Responses, 2)
+	assert.Equal(t, http.StatusCreated, routes[0].Responses[0].Status)
+	assert.IsType(t, createdUser{}, routes[0].Responses[0].Body)
+	assert.Equal(t, http.StatusConflict, routes[0].Responses[1].Status)
+
+	op := e.OpenAPI(OpenAPIInfo{}).Paths["/user"]["post"]
+	assert.NotContains(t, op.Responses, "200")
+	assert.Contains(t, op.Responses["201"].Content["application/json"].Schema.Properties, "id")
+	assert.Equal(t, "The name is already taken", op.Responses["409"].Description)
+}
+
+type responseInvalidStatus struct {
+	Response string `status:"ok"`
+}
+
+func (r *responseInvalidStatus) Handle(c *Context) error {
+	return nil
+}
+
+func TestResponseInvalidStatus(t *testing.T) {
+	_, err := transformRequestHandler("/", http.MethodGet, newInjector(), newBinder(), &responseInvalidStatus{})
+	assert.Error(t, err)
+}
+
+type responseMiddleware struct {
+	Response struct {
+		A int
+	}
+}
+
+func (r *responseMiddleware) Handle(c *Context) error {
+	r.Response.A = 1
+	return nil
+}
+
+func TestResponseFieldChained(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().POST("/user", &responseMiddleware{}, &responseHandler{})
+	e.GetRouter().GET("/list", &responseMiddleware{}, &responseDefaultStatusHandler{})
+
+	// The last Response field is written, as it is described
+	resp, content := post(t, e, "/user", map[string]string{"Name": "mustafa"})
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.JSONEq(t, `{"id": 7, "name": "mustafa"}`, content)
+
+	routes := e.Routes()
+	require.Len(t, routes[0].Responses, 2)
+	assert.Equal(t, http.StatusCreated, routes[0].Responses[0].Status)
+	assert.IsType(t, createdUser{}, routes[0].Responses[0].Body)
+
+	// A body set by the handler itself is kept
+	resp, content = post(t, e, "/user", map[string]string{"Name": "taken"})
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	assert.Equal(t, `"Name is taken"`, content)
+
+	resp, content = get(t, e, "/list")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `["a", "b"]`, content)
+}
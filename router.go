@@ -2,10 +2,12 @@ package gongular
 
 import (
 	"bytes"
+	"context"
 	"log"
 	"net/http"
 
 	"path"
+	"reflect"
 
 	"time"
 
@@ -18,6 +20,15 @@ type Router struct {
 
 	prefix   string
 	handlers []RequestHandler
+
+	// The maximum size of the request bodies, the engine's is used if zero
+	maxBodySize int64
+
+	// The injector of the handlers, which is the engine's or a child of it
+	injector *injector
+
+	// The duration after which the context of a request is done, no timeout if zero
+	timeout time.Duration
 }
 
 // NewRouter creates a new gongular2 Router
@@ -26,6 +37,7 @@ func newRouter(e *Engine) *Router {
 		engine:   e,
 		prefix:   "",
 		handlers: make([]RequestHandler, 0),
+		injector: e.injector,
 	}
 	return &r
 }
@@ -84,8 +96,11 @@ func (r *Router) Method(method, path string, handlers ...RequestHandler) {
 // repetitions while defining many paths
 func (r *Router) Group(_path string, handlers ...RequestHandler) *Router {
 	newRouter := &Router{
-		engine: r.engine,
-		prefix: path.Join(r.prefix, _path),
+		engine:      r.engine,
+		prefix:      path.Join(r.prefix, _path),
+		maxBodySize: r.maxBodySize,
+		injector:    r.injector,
+		timeout:     r.timeout,
 	}
 
 	// Copy previous handlers references
@@ -98,6 +113,31 @@ func (r *Router) Group(_path string, handlers ...RequestHandler) *Router {
 	return newRouter
 }
 
+// WithMaxBodySize returns a router with the same prefix and handlers, whose routes and groups limit the request
+// bodies to the given size in bytes instead of the engine wide limit
+func (r *Router) WithMaxBodySize(n int64) *Router {
+	newRouter := r.Group("")
+	newRouter.maxBodySize = n
+	return newRouter
+}
+
+// WithTimeout returns a router with the same prefix and handlers, whose routes and groups cancel the context of a
+// request after the duration. The chain is stopped with a DeadlineError if the handlers take longer.
+func (r *Router) WithTimeout(d time.Duration) *Router {
+	newRouter := r.Group("")
+	newRouter.timeout = d
+	return newRouter
+}
+
+// WithProviders returns a router with the same prefix and handlers, whose routes and groups are injected from a child
+// injector. The values provided to the child by fn shadow the ones with the same type and key.
+func (r *Router) WithProviders(fn func(p *Providers)) *Router {
+	newRouter := r.Group("")
+	newRouter.injector = r.injector.child()
+	fn(&Providers{injector: newRouter.injector})
+	return newRouter
+}
+
 // subpath initiates a new route with path and handlers, useful for grouping
 func (r *Router) subpath(_path string, handlers []RequestHandler) (string, []RequestHandler) {
 	combinedHandlers := r.handlers
@@ -109,17 +149,30 @@ func (r *Router) subpath(_path string, handlers []RequestHandler) (string, []Req
 
 func (r *Router) combineAndWrapHandlers(path, method string, handlers []RequestHandler) {
 	resultingPath, combinedHandlers := r.subpath(path, handlers)
-	fn := r.transformRequestHandlers(resultingPath, method, combinedHandlers)
+	fn, middleHandlers, err := r.transformRequestHandlers(resultingPath, method, combinedHandlers)
+	if err != nil {
+		r.engine.registrationError(method, resultingPath, err)
+		return
+	}
 	r.engine.actualRouter.Handle(method, resultingPath, fn)
+
+	r.engine.routes = append(r.engine.routes, &route{
+		method:   method,
+		path:     resultingPath,
+		handlers: middleHandlers,
+	})
 }
 
-func (r *Router) transformRequestHandlers(path string, method string, handlers []RequestHandler) httprouter.Handle {
+func (r *Router) transformRequestHandlers(path string, method string, handlers []RequestHandler) (httprouter.Handle, []*handlerContext, error) {
 	middleHandlers := make([]*handlerContext, len(handlers))
 
 	for i, handler := range handlers {
-		mh, err := transformRequestHandler(path, method, r.engine.injector, handler)
+		mh, err := transformRequestHandler(path, method, r.injector, r.engine.binder, handler)
+		if err == nil && i == len(handlers)-1 {
+			err = mh.checkPathVariables(path)
+		}
 		if err != nil {
-			log.Fatal(err)
+			return nil, nil, err
 		}
 		middleHandlers[i] = mh
 	}
@@ -136,8 +189,23 @@ func (r *Router) transformRequestHandlers(path string, method string, handlers [
 		buf := new(bytes.Buffer)
 		logger := log.New(buf, "", log.LstdFlags)
 
+		// Limit the body so that the handlers cannot read more than allowed
+		if limit := r.bodyLimit(); limit > 0 && req.Body != nil {
+			req.Body = newLimitedBody(req.Body, limit)
+		}
+
+		// The context of the request is done after the timeout, which stops the chain
+		if r.timeout > 0 {
+			reqCtx, cancel := context.WithTimeout(req.Context(), r.timeout)
+			defer cancel()
+			req = req.WithContext(reqCtx)
+		}
+
 		// Create a context that wraps the request, writer and logger
-		ctx := contextFromRequest(path, wr, req, ps, logger)
+		ctx := contextFromRequest(path, wr, req, ps, logger, r.engine.encoders)
+
+		// The error or the panic that stopped the chain, for the finalizers
+		var chainErr error
 
 		// For each of the handler this route has, try to execute it
 		for idx, handler := range middleHandlers {
@@ -145,15 +213,50 @@ func (r *Router) transformRequestHandlers(path string, method string, handlers [
 				FuncName: handler.name,
 			}
 
+			// Do not start the handler if the request is already canceled or timed out
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				err := DeadlineError{Err: ctxErr}
+				chainErr = err
+				ctx.StopChain()
+				r.engine.errorHandler(err, ctx)
+
+				hc.Error = err
+				hc.StopChain = true
+				routeStat.Handlers[idx] = hc
+
+				break
+			}
+
 			// Parse the parameters to the handler object
 			stHandler := time.Now()
 			fn := handler.RequestHandler
-			err := fn(ctx)
+			rp, err := fn.safeExecute(ctx)
+
+			// The error of a handler that is stopped by the context is a DeadlineError
+			if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+				err = DeadlineError{Err: ctxErr}
+			}
 
 			hc.Duration = time.Since(stHandler)
 
+			// If the handler panicked, let the panic handler produce a response and stop the chain
+			if rp != nil {
+				ctx.StopChain()
+				r.engine.panicHandler(rp.value, rp.stack, ctx)
+
+				// Put the route stats
+				hc.Panic = rp.value
+				hc.Stack = rp.stack
+				hc.StopChain = true
+				routeStat.Handlers[idx] = hc
+				chainErr = chainError(rp, err)
+
+				break
+			}
+
 			// If an error occurs, stop the chain
 			if err != nil {
+				chainErr = err
 				ctx.StopChain()
 				r.engine.errorHandler(err, ctx)
 
@@ -178,8 +281,27 @@ func (r *Router) transformRequestHandlers(path string, method string, handlers [
 			routeStat.Handlers[idx] = hc
 		}
 
+		// The last handler can ignore the context and return after it is done, which must not be written as a success.
+		// A handler that has stopped the chain has chosen its response, which is written anyway.
+		if ctxErr := ctx.Err(); chainErr == nil && !ctx.stopChain && ctxErr != nil {
+			chainErr = DeadlineError{Err: ctxErr}
+			r.engine.errorHandler(chainErr, ctx)
+		}
+
+		// Release the provided values before writing the response
+		routeStat.FinalizerErrors = ctx.runFinalizers(chainErr)
+		for _, err := range routeStat.FinalizerErrors {
+			logger.Println("A finalizer has failed:", err)
+		}
+
+		// The handlers have succeeded, but the response is not if the values are not released, i.e. a failed commit
+		if chainErr == nil && len(routeStat.FinalizerErrors) > 0 {
+			r.engine.errorHandler(routeStat.FinalizerErrors[0], ctx)
+		}
+
 		// Save final stats
 		routeStat.ResponseSize = ctx.Finalize()
+		ctx.cleanup()
 		routeStat.ResponseCode = ctx.status
 		routeStat.TotalDuration = time.Since(st)
 		routeStat.Logs = buf
@@ -189,5 +311,29 @@ func (r *Router) transformRequestHandlers(path string, method string, handlers [
 		}
 	}
 
-	return fn
+	return fn, middleHandlers, nil
+}
+
+// bodyLimit returns the maximum size of the request bodies for the routes of this router
+func (r *Router) bodyLimit() int64 {
+	if r.maxBodySize != 0 {
+		return r.maxBodySize
+	}
+	return r.engine.maxBodySize
+}
+
+// route is a registered route with its analyzed handlers, kept for introspection
+type route struct {
+	method   string
+	path     string
+	handlers []*handlerContext
+}
+
+// RouteInfo describes a registered route, with the types of the handlers in the order they are executed and the
+// responses declared by them, where the success response comes first if it is declared
+type RouteInfo struct {
+	Method    string
+	Path      string
+	Handlers  []reflect.Type
+	Responses []ResponseDescription
 }
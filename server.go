@@ -0,0 +1,199 @@
+package gongular
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ServerOptions holds the configuration of the http.Server owned by the Engine
+type ServerOptions struct {
+	// Addr is the TCP address to listen on, ":http" if empty
+	Addr string
+
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+
+	// TLSConfig, CertFile and KeyFile are used to serve HTTPs if CertFile and KeyFile are set
+	TLSConfig *tls.Config
+	CertFile  string
+	KeyFile   string
+
+	// ShutdownTimeout is the deadline for draining the in-flight requests when Run is cancelled,
+	// zero means waiting for them indefinitely
+	ShutdownTimeout time.Duration
+}
+
+// Server creates the http.Server that serves the engine with the given options. The returned server can be
+// further customized before calling Run.
+func (e *Engine) Server(opts ServerOptions) *http.Server {
+	e.serverMu.Lock()
+	defer e.serverMu.Unlock()
+	return e.newServer(opts)
+}
+
+// newServer creates the server of the engine, serverMu must be held. If the engine is already shut down, the server
+// is closed as well, so that it does not start serving after the shutdown.
+func (e *Engine) newServer(opts ServerOptions) *http.Server {
+	srv := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           e.actualRouter,
+		ReadTimeout:       opts.ReadTimeout,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+		TLSConfig:         opts.TLSConfig,
+	}
+
+	// Hijacked websocket connections are not tracked by http.Server, so we close them ourselves
+	srv.RegisterOnShutdown(e.wsRouter.connections.closeAll)
+
+	if e.shutdown {
+		_ = srv.Close()
+	}
+
+	e.server = srv
+	e.serverOptions = opts
+	return srv
+}
+
+// ownServer returns the server of the engine with its options, a server with the default options is created if
+// there is none
+func (e *Engine) ownServer() (*http.Server, ServerOptions) {
+	e.serverMu.Lock()
+	defer e.serverMu.Unlock()
+
+	if e.server == nil {
+		e.newServer(ServerOptions{})
+	}
+	return e.server, e.serverOptions
+}
+
+// Run starts the server created with Server, or a server with default options if it is not created yet, and
+// blocks until the server fails or the given context is done. When the context is done, the server is shut down
+// gracefully within the configured ShutdownTimeout. It does not start if any route could not be registered.
+func (e *Engine) Run(ctx context.Context) error {
+	err := e.Validate()
+	if err != nil {
+		return err
+	}
+
+	srv, opts := e.ownServer()
+
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	for _, fn := range e.onStart {
+		fn(listener.Addr())
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if opts.CertFile != "" && opts.KeyFile != "" {
+			errCh <- srv.ServeTLS(listener, opts.CertFile, opts.KeyFile)
+		} else {
+			errCh <- srv.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx := context.Background()
+	if opts.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, opts.ShutdownTimeout)
+		defer cancel()
+	}
+
+	err = e.Shutdown(shutdownCtx)
+	if serveErr := <-errCh; serveErr != http.ErrServerClosed {
+		return serveErr
+	}
+	return err
+}
+
+// Shutdown gracefully shuts down the server by waiting the in-flight requests until the context is done, and
+// closes the open websocket connections. It can be called while the server is starting in another goroutine, the
+// server does not serve afterwards.
+func (e *Engine) Shutdown(ctx context.Context) error {
+	for _, fn := range e.onShutdown {
+		fn()
+	}
+
+	e.serverMu.Lock()
+	e.shutdown = true
+	srv := e.server
+	e.serverMu.Unlock()
+
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
+
+// OnStart registers a function that is called with the listening address when the server starts
+func (e *Engine) OnStart(fn func(addr net.Addr)) {
+	e.onStart = append(e.onStart, fn)
+}
+
+// OnShutdown registers a function that is called when the server starts shutting down
+func (e *Engine) OnShutdown(fn func()) {
+	e.onShutdown = append(e.onShutdown, fn)
+}
+
+// wsConnections keeps track of the upgraded websocket connections so that they can be closed on shutdown
+type wsConnections struct {
+	mu    sync.Mutex
+	conns map[*websocket.Conn]struct{}
+}
+
+func newWSConnections() *wsConnections {
+	return &wsConnections{
+		conns: make(map[*websocket.Conn]struct{}),
+	}
+}
+
+func (w *wsConnections) add(conn *websocket.Conn) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.conns[conn] = struct{}{}
+}
+
+func (w *wsConnections) remove(conn *websocket.Conn) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.conns, conn)
+}
+
+// closeAll sends a close message to all open connections and closes them
+func (w *wsConnections) closeAll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for conn := range w.conns {
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		_ = conn.Close()
+		delete(w.conns, conn)
+	}
+}
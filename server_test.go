@@ -0,0 +1,138 @@
+package gongular
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type slowHandler struct{}
+
+func (s *slowHandler) Handle(c *Context) error {
+	time.Sleep(100 * time.Millisecond)
+	c.SetBody("done")
+	return nil
+}
+
+func TestEngine_RunGracefulShutdown(t *testing.T) {
+	e := newEngineTest()
+	e.GetRouter().GET("/slow", &slowHandler{})
+	e.Server(ServerOptions{
+		Addr:            "127.0.0.1:0",
+		ReadTimeout:     time.Second,
+		ShutdownTimeout: time.Second,
+	})
+
+	addrCh := make(chan net.Addr, 1)
+	e.OnStart(func(addr net.Addr) {
+		addrCh <- addr
+	})
+
+	shutdownCalled := false
+	e.OnShutdown(func() {
+		shutdownCalled = true
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- e.Run(ctx)
+	}()
+
+	addr := <-addrCh
+	type result struct {
+		code int
+		body string
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("http://%s/slow", addr))
+		if err != nil {
+			resCh <- result{}
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		resCh <- result{resp.StatusCode, string(b)}
+	}()
+
+	// Let the request arrive before shutting down
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+
+	res := <-resCh
+	assert.Equal(t, http.StatusOK, res.code)
+	assert.Equal(t, `"done"`, res.body)
+
+	assert.NoError(t, <-runErr)
+	assert.True(t, shutdownCalled)
+}
+
+func TestEngine_ShutdownWhileStarting(t *testing.T) {
+	e := newEngineTest()
+
+	// Shutdown can be called before or after the server is created by the goroutine
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- e.ListenAndServe("127.0.0.1:0")
+	}()
+	require.NoError(t, e.Shutdown(context.Background()))
+
+	select {
+	case err := <-serveErr:
+		assert.Equal(t, http.ErrServerClosed, err)
+	case <-time.After(time.Second):
+		t.Fatal("the server has not stopped")
+	}
+}
+
+type wsBlockingTest struct{}
+
+func (w *wsBlockingTest) Before(c *Context) (http.Header, error) {
+	return nil, nil
+}
+
+func (w *wsBlockingTest) Handle(conn *websocket.Conn) {
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestEngine_ShutdownClosesWebsockets(t *testing.T) {
+	e := newEngineTest()
+	e.GetWSRouter().Handle("/ws", &wsBlockingTest{})
+	e.Server(ServerOptions{Addr: "127.0.0.1:0"})
+
+	addrCh := make(chan net.Addr, 1)
+	e.OnStart(func(addr net.Addr) {
+		addrCh <- addr
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- e.Run(ctx)
+	}()
+
+	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%s/ws", <-addrCh), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Make sure the connection is registered before shutting down
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+	assert.NoError(t, <-runErr)
+}
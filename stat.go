@@ -7,26 +7,30 @@ import (
 	"time"
 )
 
-// HandlerStat keeps duration, error (if exists) and whether the chain was stopped for
-//  a single handler
+// HandlerStat keeps duration, error (if exists), the recovered panic value and its stack
+// trace (if the handler panicked) and whether the chain was stopped for a single handler
 type HandlerStat struct {
 	FuncName  string
 	Duration  time.Duration
 	Error     error
+	Panic     interface{}
+	Stack     []byte
 	StopChain bool
 }
 
 // RouteStat holds information for the whole route, which path it matched, the written
 // response size and the final status code for the request, and finally the logs generated
 // by all handlers and it includes the individual HandlerStat this route consists of.
+// FinalizerErrors are the errors returned by the finalizers of the provided values.
 type RouteStat struct {
-	Request       *http.Request
-	Handlers      []HandlerStat
-	MatchedPath   string
-	TotalDuration time.Duration
-	ResponseSize  int
-	ResponseCode  int
-	Logs          *bytes.Buffer
+	Request         *http.Request
+	Handlers        []HandlerStat
+	MatchedPath     string
+	TotalDuration   time.Duration
+	ResponseSize    int
+	ResponseCode    int
+	Logs            *bytes.Buffer
+	FinalizerErrors []error
 }
 
 // RouteCallback is the interface to what to do with a given route
@@ -52,6 +52,18 @@ func respWrap(t *testing.T, e *Engine, path, method string, reader io.Reader) (*
 	return resp, string(p)
 }
 
+func serve(t *testing.T, e *Engine, req *http.Request) (*httptest.ResponseRecorder, string) {
+	resp := httptest.NewRecorder()
+
+	e.GetHandler().ServeHTTP(resp, req)
+	p, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fail()
+		return resp, ""
+	}
+	return resp, string(p)
+}
+
 func get(t *testing.T, e *Engine, path string) (*httptest.ResponseRecorder, string) {
 	return respWrap(t, e, path, "GET", nil)
 }
@@ -23,20 +23,23 @@ type WebsocketHandler interface {
 // WSRouter wraps the Engine with the ability to map WebsocketHandler to routes. Currently it does not support
 // sub-routing but it supports injections, param and query parameter bindings.
 type WSRouter struct {
-	engine *Engine
+	engine      *Engine
+	connections *wsConnections
 }
 
 func newWSRouter(e *Engine) *WSRouter {
 	return &WSRouter{
-		engine: e,
+		engine:      e,
+		connections: newWSConnections(),
 	}
 }
 
 // Handle registers the given Websocket handler if
 func (r *WSRouter) Handle(path string, handler WebsocketHandler) {
-	mh, err := transformWebsocketHandler(path, r.engine.injector, handler)
+	mh, err := transformWebsocketHandler(path, r.engine.injector, r.engine.binder, r.connections, handler)
 	if err != nil {
-		log.Fatal(err)
+		r.engine.registrationError(http.MethodGet, path, err)
+		return
 	}
 
 	fn := func(wr http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -45,12 +48,30 @@ func (r *WSRouter) Handle(path string, handler WebsocketHandler) {
 		logger := log.New(buf, "", log.LstdFlags)
 
 		// Create a context that wraps the request, writer and logger
-		ctx := contextFromRequest(path, wr, req, ps, logger)
+		ctx := contextFromRequest(path, wr, req, ps, logger, r.engine.encoders)
 
 		// Parse the parameters to the handler object
 		fn := mh.RequestHandler
-		err := fn(ctx)
-		if err != nil {
+		rp, err := fn.safeExecute(ctx)
+
+		// The websocket is closed when the handler returns, so the provided values can be released
+		ferrs := ctx.runFinalizers(chainError(rp, err))
+		for _, ferr := range ferrs {
+			logger.Println("A finalizer has failed:", ferr)
+		}
+
+		if rp == nil && err == nil && len(ferrs) > 0 {
+			err = ferrs[0]
+		}
+
+		if rp != nil {
+			r.engine.panicHandler(rp.value, rp.stack, ctx)
+
+			// The response cannot be written if the connection is already taken over by the websocket
+			if !ctx.upgraded {
+				ctx.Finalize()
+			}
+		} else if err != nil {
 			r.engine.errorHandler(err, ctx)
 		}
 	}
@@ -66,3 +66,20 @@ func TestWS_Simple(t *testing.T) {
 
 	assert.Equal(t, "selam:5:musti:true", result)
 }
+
+type wsPanicTest struct{}
+
+func (w *wsPanicTest) Before(c *Context) (http.Header, error) {
+	panic("before")
+}
+
+func (w *wsPanicTest) Handle(conn *websocket.Conn) {}
+
+func TestWS_PanicInBefore(t *testing.T) {
+	e := newEngineTest()
+	e.GetWSRouter().Handle("/ws", &wsPanicTest{})
+
+	resp, content := get(t, e, "/ws")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, `"Internal Server Error"`, content)
+}